@@ -3,57 +3,178 @@ package main
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"io/fs"
+	"log"
+	"math/rand/v2"
+	"net"
 	"net/http"
+	"net/url"
+	"os"
 	"os/signal"
+	"path/filepath"
+	"strconv"
 	"syscall"
 	"time"
+	_ "time/tzdata" // outlet time zones must resolve on images without zoneinfo
 
+	"github.com/dominikuswilly/nofu-be_product/internal/auth"
 	"github.com/dominikuswilly/nofu-be_product/internal/config"
 	"github.com/dominikuswilly/nofu-be_product/internal/handler"
+	"github.com/dominikuswilly/nofu-be_product/internal/health"
+	"github.com/dominikuswilly/nofu-be_product/internal/imaging"
+	"github.com/dominikuswilly/nofu-be_product/internal/metrics"
+	"github.com/dominikuswilly/nofu-be_product/internal/middleware"
+	"github.com/dominikuswilly/nofu-be_product/internal/migrate"
+	"github.com/dominikuswilly/nofu-be_product/internal/ratelimit"
 	"github.com/dominikuswilly/nofu-be_product/internal/repository"
 	"github.com/dominikuswilly/nofu-be_product/internal/server"
+	"github.com/dominikuswilly/nofu-be_product/internal/storage"
+	"github.com/dominikuswilly/nofu-be_product/internal/tracing"
 	"github.com/dominikuswilly/nofu-be_product/internal/usecase"
-	_ "github.com/lib/pq"
+	"github.com/dominikuswilly/nofu-be_product/migrations"
+	"github.com/lib/pq"
+	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
 func main() {
-	// 1. Logger
-	logger, _ := zap.NewProduction()
+	// 1. Config
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// 2. Logger
+	logger := newLogger(cfg)
 	defer logger.Sync()
+	// Code without a request-scoped logger logs through the global one
+	zap.ReplaceGlobals(logger)
 
-	// 2. Config
-	cfg := config.Load()
+	// 3. Tracing
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Options{
+		Exporter:    cfg.Tracing.Exporter,
+		ServiceName: cfg.Tracing.ServiceName,
+		SampleRatio: cfg.Tracing.SampleRatio,
+	})
+	if err != nil {
+		logger.Fatal("Failed to initialize tracing", zap.Error(err))
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			logger.Error("Failed to flush spans", zap.Error(err))
+		}
+	}()
 
-	// 3. Database
-	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-		cfg.DBHost, cfg.DBPort, cfg.DBUser, cfg.DBPassword, cfg.DBName)
+	// 4. Storage
+	checker := health.NewChecker(cfg.Server.ReadyTimeout)
+	var repos repositories
+	var dbSystem string
+	switch cfg.Storage.Backend {
+	case "postgres":
+		db := openPostgres(cfg, logger)
+		defer db.Close()
+		if migrateOnly := prepareSchema(cfg, db, migrate.Postgres, migrations.FS, logger); migrateOnly {
+			return
+		}
+		repos, dbSystem = newPostgresRepositories(db), "postgresql"
+		registerDBMetrics(db, cfg.DB.Name, logger)
+		checker.Add("database", db.PingContext)
+	case "sqlite":
+		db := openSQLite(cfg, logger)
+		defer db.Close()
+		if migrateOnly := prepareSchema(cfg, db, migrate.SQLite, migrations.SQLiteFS, logger); migrateOnly {
+			return
+		}
+		repos, dbSystem = newSQLiteRepositories(db), "sqlite"
+		registerDBMetrics(db, filepath.Base(cfg.Storage.SQLitePath), logger)
+		checker.Add("database", db.PingContext)
+	case "memory":
+		if len(os.Args) > 1 && os.Args[1] == "migrate" {
+			logger.Fatal("The migrate command requires STORAGE=postgres or STORAGE=sqlite")
+		}
+		logger.Warn("Using in-memory storage; all data is lost when the service stops")
+		repos, dbSystem = newMemoryRepositories(), "memory"
+	default:
+		logger.Fatal("Invalid STORAGE, expected postgres, sqlite or memory", zap.String("storage", cfg.Storage.Backend))
+	}
 
-	logger.Info("Connecting to database", zap.String("dsn_masked", fmt.Sprintf("host=%s user=%s dbname=%s", cfg.DBHost, cfg.DBUser, cfg.DBName)))
+	if dbSystem != "memory" && cfg.DB.QueryTimeout > 0 {
+		repos = repos.instrument(repository.QueryTimeout(cfg.DB.QueryTimeout))
+	}
+	repos = repos.instrument(metrics.QueryHook)
+	// Scrapes count products without tracing, they are not part of any request
+	if err := metrics.RegisterProducts(repos.products, int64(cfg.Metrics.LowStockThreshold), 5*time.Second, cfg.Metrics.ProductsCacheTTL); err != nil {
+		logger.Fatal("Failed to register product metrics", zap.Error(err))
+	}
+	repos = repos.instrument(tracing.QueryHook(dbSystem))
 
-	db, err := sql.Open("postgres", dsn)
+	// 5. Media storage
+	store, err := storage.NewLocalStorage(cfg.Media.Dir, cfg.Media.BaseURL)
 	if err != nil {
-		logger.Fatal("Failed to open database connection", zap.Error(err))
+		logger.Fatal("Failed to initialize media storage", zap.Error(err))
 	}
-	defer db.Close()
 
-	if err := db.Ping(); err != nil {
-		logger.Fatal("Failed to ping database", zap.Error(err))
+	// 6. Authentication
+	authStats := &auth.RemoteStats{}
+	if err := metrics.RegisterAuthRemote(authStats); err != nil {
+		logger.Fatal("Failed to register auth metrics", zap.Error(err))
 	}
 
-	// 4. Layers Setup
-	repo := repository.NewPostgresProductRepository(db)
-	uc := usecase.NewProductUsecase(repo)
-	h := handler.NewProductHandler(uc, logger, cfg.AuthServiceURL)
+	validator, err := newAuthValidator(cfg, authStats)
+	if err != nil {
+		logger.Fatal("Failed to initialize token validation", zap.Error(err))
+	}
+	logger.Info("Token validation configured", zap.String("mode", cfg.Auth.Mode))
+	if cfg.Server.ReadyCheckAuth {
+		if url := authCheckURL(cfg); url != "" {
+			client := auth.NewHTTPClient(cfg.Server.ReadyTimeout)
+			checker.Add("auth", func(ctx context.Context) error {
+				return auth.CheckReachable(ctx, client, url)
+			})
+		}
+	}
 
-	// 5. Server
-	srv := server.NewServer(cfg, h, logger)
+	policy := auth.DefaultPolicy()
+	if cfg.Auth.RBACPolicy != "" {
+		if policy, err = auth.ParsePolicy(cfg.Auth.RBACPolicy); err != nil {
+			logger.Fatal("Invalid RBAC_POLICY", zap.Error(err))
+		}
+	}
+	logger.Info("Access policy configured", zap.Strings("roles", policy.Roles()))
 
-	// 6. Graceful Shutdown
+	location, err := time.LoadLocation(cfg.Tenant.DefaultTimezone)
+	if err != nil {
+		logger.Fatal("Invalid DEFAULT_TIMEZONE", zap.Error(err))
+	}
+
+	limiter, closeLimiter := newRateLimiter(cfg, logger)
+	defer closeLimiter()
+
+	// 7. Layers Setup
+	imageWorker := imaging.NewWorker(repos.products, repos.versions, repos.tx, store, logger, 100, cfg.Media.MaxImagePixels)
+	uc := usecase.NewProductUsecase(repos.products, repos.audit, repos.versions, repos.outlets, repos.tx, store, imageWorker, location, policy)
+	outletUC := usecase.NewOutletUsecase(repos.outlets, repos.products, repos.audit, repos.tx)
+	changeRequestUC := usecase.NewChangeRequestUsecase(repos.changeRequests, repos.products, repos.audit, repos.versions, repos.notifications, repos.tx)
+	h := handler.NewProductHandler(uc, changeRequestUC, logger, validator, policy, limiter, cfg.Tenant.DefaultID)
+	outletHandler := handler.NewOutletHandler(outletUC, logger, validator, policy, limiter, cfg.Tenant.DefaultID)
+	catalogHandler := handler.NewCatalogHandler(uc, logger, cfg.Catalog.CacheMaxAge, limiter, cfg.Tenant.DefaultID)
+	changeRequestHandler := handler.NewChangeRequestHandler(changeRequestUC, logger, validator, policy, limiter, cfg.Tenant.DefaultID)
+	healthHandler := handler.NewHealthHandler(checker)
+
+	// 8. Server
+	srv := server.NewServer(cfg, h, outletHandler, catalogHandler, changeRequestHandler, healthHandler, logger)
+
+	// 9. Graceful Shutdown
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
+	imageWorker.Start(ctx, 2)
+
 	go func() {
 		if err := srv.Start(); err != nil && err != http.ErrServerClosed {
 			logger.Fatal("Server start failed", zap.Error(err))
@@ -63,12 +184,337 @@ func main() {
 	<-ctx.Done()
 	logger.Info("Shutting down gracefully...")
 
-	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	// Report not ready first so load balancers stop routing here while requests still succeed
+	checker.SetShuttingDown()
+	time.Sleep(cfg.Server.ShutdownDelay)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
 	defer cancel()
 
 	if err := srv.Shutdown(shutdownCtx); err != nil {
 		logger.Fatal("Server forced to shutdown", zap.Error(err))
 	}
 
+	// Let in-flight image jobs finish; queued ones are regenerated on the next upload
+	imageWorker.Wait()
+
 	logger.Info("Server exiting")
 }
+
+// newRateLimiter returns the limiter of the route groups with its store, and the function
+// that releases the store. It returns a nil limiter, which limits nothing, when rate
+// limiting is disabled.
+func newRateLimiter(cfg *config.Config, logger *zap.Logger) (*middleware.RateLimiter, func()) {
+	rl := cfg.RateLimit
+	if !rl.Enabled {
+		logger.Warn("Rate limiting is disabled")
+		return nil, func() {}
+	}
+	if len(cfg.Server.TrustedProxies) == 0 {
+		logger.Warn("No trusted proxies: behind a load balancer or reverse proxy all clients share its per IP rate limits, set TRUSTED_PROXIES to its IPs or CIDRs")
+	}
+
+	limits := map[string]ratelimit.Limit{
+		middleware.RateGroupAuth:    {Rate: rl.AuthRate, Burst: rl.AuthBurst},
+		middleware.RateGroupRead:    {Rate: rl.ReadRate, Burst: rl.ReadBurst},
+		middleware.RateGroupWrite:   {Rate: rl.WriteRate, Burst: rl.WriteBurst},
+		middleware.RateGroupCatalog: {Rate: cfg.Catalog.RateLimit, Burst: cfg.Catalog.RateBurst},
+	}
+	if rl.Store == "memory" {
+		return middleware.NewRateLimiter(ratelimit.NewMemoryStore(), limits, rl.APIKeys), func() {}
+	}
+
+	opts, err := redis.ParseURL(rl.RedisURL)
+	if err != nil {
+		logger.Fatal("Invalid RATE_LIMIT_REDIS_URL", zap.Error(err))
+	}
+	client := redis.NewClient(opts)
+	// Requests are not limited while Redis is unreachable, so this is only worth a warning
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		logger.Warn("Rate limit store unreachable, requests are not limited until it is", zap.Error(err))
+	}
+	logger.Info("Rate limits shared through Redis", zap.String("addr", opts.Addr))
+	return middleware.NewRateLimiter(ratelimit.NewRedisStore(client, rl.RedisPrefix), limits, rl.APIKeys), func() { client.Close() }
+}
+
+// repositories holds the data access of the service, backed by the storage selected by STORAGE
+type repositories struct {
+	products       repository.ProductRepository
+	audit          repository.AuditRepository
+	versions       repository.ProductVersionRepository
+	outlets        repository.OutletRepository
+	changeRequests repository.ChangeRequestRepository
+	notifications  repository.NotificationRepository
+	// tx runs transactions across the repositories above
+	tx repository.Transactor
+}
+
+// instrument wraps every repository so that hook observes its calls
+func (r repositories) instrument(hook repository.QueryHook) repositories {
+	return repositories{
+		products:       repository.NewInstrumentedProductRepository(r.products, hook),
+		audit:          repository.NewInstrumentedAuditRepository(r.audit, hook),
+		versions:       repository.NewInstrumentedProductVersionRepository(r.versions, hook),
+		outlets:        repository.NewInstrumentedOutletRepository(r.outlets, hook),
+		changeRequests: repository.NewInstrumentedChangeRequestRepository(r.changeRequests, hook),
+		notifications:  repository.NewInstrumentedNotificationRepository(r.notifications, hook),
+		tx:             r.tx,
+	}
+}
+
+func newPostgresRepositories(db *sql.DB) repositories {
+	return repositories{
+		products:       repository.NewPostgresProductRepository(db),
+		audit:          repository.NewPostgresAuditRepository(db),
+		versions:       repository.NewPostgresProductVersionRepository(db),
+		outlets:        repository.NewPostgresOutletRepository(db),
+		changeRequests: repository.NewPostgresChangeRequestRepository(db),
+		notifications:  repository.NewPostgresNotificationRepository(db),
+		tx:             repository.NewSQLTransactor(db),
+	}
+}
+
+func newSQLiteRepositories(db *sql.DB) repositories {
+	return repositories{
+		products:       repository.NewSQLiteProductRepository(db),
+		audit:          repository.NewSQLiteAuditRepository(db),
+		versions:       repository.NewSQLiteProductVersionRepository(db),
+		outlets:        repository.NewSQLiteOutletRepository(db),
+		changeRequests: repository.NewSQLiteChangeRequestRepository(db),
+		notifications:  repository.NewSQLiteNotificationRepository(db),
+		tx:             repository.NewSQLTransactor(db),
+	}
+}
+
+func newMemoryRepositories() repositories {
+	return repositories{
+		products:       repository.NewMemoryProductRepository(),
+		audit:          repository.NewMemoryAuditRepository(),
+		versions:       repository.NewMemoryProductVersionRepository(),
+		outlets:        repository.NewMemoryOutletRepository(),
+		changeRequests: repository.NewMemoryChangeRequestRepository(),
+		notifications:  repository.NewMemoryNotificationRepository(),
+		tx:             repository.NewMemoryTransactor(),
+	}
+}
+
+// newLogger creates the JSON logger at the configured level
+func newLogger(cfg *config.Config) *zap.Logger {
+	zapCfg := zap.NewProductionConfig()
+	// The level was validated with the config
+	level, _ := zapcore.ParseLevel(cfg.Log.Level)
+	zapCfg.Level = zap.NewAtomicLevelAt(level)
+	logger, err := zapCfg.Build()
+	if err != nil {
+		log.Fatalf("failed to create logger: %v", err)
+	}
+	return logger
+}
+
+// openPostgres connects to the PostgreSQL database from the config
+func openPostgres(cfg *config.Config, logger *zap.Logger) *sql.DB {
+	// A URL escapes whatever characters the password contains
+	params := url.Values{"sslmode": {cfg.DB.SSLMode}}
+	for name, value := range map[string]string{
+		"sslrootcert": cfg.DB.SSLRootCert,
+		"sslcert":     cfg.DB.SSLCert,
+		"sslkey":      cfg.DB.SSLKey,
+	} {
+		if value != "" {
+			params.Set(name, value)
+		}
+	}
+	dsn := (&url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(cfg.DB.User, cfg.DB.Password),
+		Host:     net.JoinHostPort(cfg.DB.Host, strconv.Itoa(cfg.DB.Port)),
+		Path:     "/" + cfg.DB.Name,
+		RawQuery: params.Encode(),
+	}).String()
+
+	logger.Info("Connecting to database", zap.String("dsn_masked", fmt.Sprintf("host=%s user=%s dbname=%s sslmode=%s", cfg.DB.Host, cfg.DB.User, cfg.DB.Name, cfg.DB.SSLMode)))
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		logger.Fatal("Failed to open database connection", zap.Error(err))
+	}
+	db.SetMaxOpenConns(cfg.DB.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.DB.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.DB.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(cfg.DB.ConnMaxIdleTime)
+	if err := waitForDB(db, cfg.DB.ConnectTimeout, logger); err != nil {
+		logger.Fatal("Failed to connect to database", zap.Error(err))
+	}
+	return db
+}
+
+// waitForDB pings db until it answers, backing off exponentially with jitter, for at most
+// timeout. It gives up at once when the database rejects the credentials or does not exist,
+// since retrying cannot fix that.
+func waitForDB(db *sql.DB, timeout time.Duration, logger *zap.Logger) error {
+	const maxDelay = 10 * time.Second
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	delay := 500 * time.Millisecond
+	for attempt := 1; ; attempt++ {
+		err := db.PingContext(ctx)
+		if err == nil {
+			return nil
+		}
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && (pqErr.Code.Class() == "28" || pqErr.Code == "3D000") {
+			return err
+		}
+
+		wait := delay/2 + rand.N(delay/2)
+		logger.Warn("Database not reachable yet, retrying",
+			zap.Int("attempt", attempt), zap.Duration("retryIn", wait), zap.Error(err))
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("gave up after %d attempts in %s: %w", attempt, timeout, err)
+		case <-time.After(wait):
+		}
+		delay = min(delay*2, maxDelay)
+	}
+}
+
+// openSQLite opens the SQLite database file from the config, creating it if needed
+func openSQLite(cfg *config.Config, logger *zap.Logger) *sql.DB {
+	logger.Info("Opening SQLite database", zap.String("path", cfg.Storage.SQLitePath))
+
+	if err := os.MkdirAll(filepath.Dir(cfg.Storage.SQLitePath), 0o755); err != nil {
+		logger.Fatal("Failed to create SQLite directory", zap.Error(err))
+	}
+	db, err := repository.OpenSQLite(cfg.Storage.SQLitePath)
+	if err != nil {
+		logger.Fatal("Failed to open SQLite database", zap.Error(err))
+	}
+	return db
+}
+
+// registerDBMetrics exposes the connection pool statistics of db
+func registerDBMetrics(db *sql.DB, name string, logger *zap.Logger) {
+	if err := metrics.RegisterDB(db, name); err != nil {
+		logger.Fatal("Failed to register database metrics", zap.Error(err))
+	}
+}
+
+// prepareSchema runs the migrate subcommand if it was given, reporting true so the caller
+// exits, or otherwise applies pending migrations when DB_MIGRATE_ON_START is set
+func prepareSchema(cfg *config.Config, db *sql.DB, dialect migrate.Dialect, fsys fs.FS, logger *zap.Logger) bool {
+	migrator, err := migrate.New(db, dialect, fsys, logger)
+	if err != nil {
+		logger.Fatal("Failed to load migrations", zap.Error(err))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(context.Background(), migrator, os.Args[2:]); err != nil {
+			logger.Fatal("Migration failed", zap.Error(err))
+		}
+		return true
+	}
+	if cfg.DB.MigrateOnStart {
+		if _, err := migrator.Up(context.Background()); err != nil {
+			logger.Fatal("Failed to apply migrations", zap.Error(err))
+		}
+	}
+	return false
+}
+
+// newAuthValidator builds the token validator selected by AUTH_MODE
+func newAuthValidator(cfg *config.Config, stats *auth.RemoteStats) (auth.Validator, error) {
+	client := auth.NewHTTPClient(cfg.Auth.HTTPTimeout)
+	remote := auth.NewRemoteValidator(cfg.Auth.ServiceURL, client, auth.RemoteOptions{
+		CacheTTL:         cfg.Auth.CacheTTL,
+		CacheSize:        cfg.Auth.CacheSize,
+		BreakerThreshold: cfg.Auth.BreakerFailures,
+		BreakerCooldown:  cfg.Auth.BreakerCooldown,
+		Stats:            stats,
+	})
+	if cfg.Auth.Mode == "remote" {
+		return remote, nil
+	}
+
+	var keys *auth.KeySet
+	switch {
+	case cfg.Auth.JWKSFile != "":
+		var err error
+		if keys, err = auth.LoadKeySetFile(cfg.Auth.JWKSFile); err != nil {
+			return nil, err
+		}
+	case cfg.Auth.JWKSURL != "":
+		keys = auth.NewRemoteKeySet(cfg.Auth.JWKSURL, client, cfg.Auth.JWKSRefresh)
+	default:
+		return nil, fmt.Errorf("AUTH_MODE %q requires AUTH_JWKS_URL or AUTH_JWKS_FILE", cfg.Auth.Mode)
+	}
+	local := auth.NewJWTValidator(keys, cfg.Auth.JWTIssuer, cfg.Auth.JWTAudience)
+
+	switch cfg.Auth.Mode {
+	case "jwt":
+		return local, nil
+	case "jwt+remote":
+		return auth.NewFallbackValidator(local, remote), nil
+	default:
+		return nil, fmt.Errorf("unknown AUTH_MODE %q", cfg.Auth.Mode)
+	}
+}
+
+// authCheckURL returns the auth endpoint token validation depends on, or "" when tokens
+// are verified with a local key set only
+func authCheckURL(cfg *config.Config) string {
+	switch {
+	case cfg.Auth.Mode == "remote" || cfg.Auth.Mode == "jwt+remote":
+		return cfg.Auth.ServiceURL
+	case cfg.Auth.JWKSFile == "":
+		return cfg.Auth.JWKSURL
+	default:
+		return ""
+	}
+}
+
+// runMigrate implements the "migrate up", "migrate down [steps]" and "migrate status" subcommands
+func runMigrate(ctx context.Context, migrator *migrate.Migrator, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: migrate up | down [steps] | status")
+	}
+
+	switch args[0] {
+	case "up":
+		n, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Applied %d migration(s)\n", n)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			var err error
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				return fmt.Errorf("steps must be a positive integer, got %q", args[1])
+			}
+		}
+		n, err := migrator.Down(ctx, steps)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Reverted %d migration(s)\n", n)
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, s := range statuses {
+			applied := "pending"
+			if s.AppliedAt != nil {
+				applied = "applied " + s.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Printf("%06d %-32s %s\n", s.Version, s.Name, applied)
+		}
+	default:
+		return fmt.Errorf("unknown migrate command %q, expected up, down or status", args[0])
+	}
+	return nil
+}
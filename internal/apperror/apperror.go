@@ -0,0 +1,137 @@
+// Package apperror defines the typed errors shared by the repository, usecase and handler
+// layers. Each error has a kind, which decides the HTTP status, and a stable code that
+// clients can rely on.
+package apperror
+
+import "errors"
+
+// Kind classifies an error by what the client did wrong, or did not
+type Kind string
+
+const (
+	KindInternal     Kind = "internal"
+	KindValidation   Kind = "validation"
+	KindUnauthorized Kind = "unauthorized"
+	KindForbidden    Kind = "forbidden"
+	KindNotFound     Kind = "not_found"
+	KindConflict     Kind = "conflict"
+	KindRateLimited  Kind = "rate_limited"
+	KindUnavailable  Kind = "unavailable"
+)
+
+// Error is an error that is safe to show to clients. Title is the same for every
+// occurrence of Code; Detail optionally explains this occurrence.
+type Error struct {
+	Kind   Kind
+	Code   string
+	Title  string
+	Detail string
+	// Extensions are extra members added to the problem response
+	Extensions map[string]interface{}
+	// Violations lists the invalid request fields of a validation error
+	Violations []Violation
+	// Err is the underlying cause. It is logged but never shown to clients.
+	Err error
+}
+
+func (e *Error) Error() string {
+	msg := e.Title
+	if e.Detail != "" {
+		msg += ": " + e.Detail
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is matches errors with the same code, so errors.Is(err, ErrSomething) holds for copies
+// of ErrSomething made by WithDetail or Wrap
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// WithDetail returns a copy of e explaining this occurrence
+func (e *Error) WithDetail(detail string) *Error {
+	c := *e
+	c.Detail = detail
+	return &c
+}
+
+// WithExtension returns a copy of e with an extra problem member
+func (e *Error) WithExtension(key string, value interface{}) *Error {
+	c := *e
+	c.Extensions = make(map[string]interface{}, len(e.Extensions)+1)
+	for k, v := range e.Extensions {
+		c.Extensions[k] = v
+	}
+	c.Extensions[key] = value
+	return &c
+}
+
+// WithViolations returns a copy of e listing the invalid request fields
+func (e *Error) WithViolations(violations ...Violation) *Error {
+	c := *e
+	c.Violations = append(append([]Violation(nil), e.Violations...), violations...)
+	return &c
+}
+
+// Wrap returns a copy of e caused by err
+func (e *Error) Wrap(err error) *Error {
+	c := *e
+	c.Err = err
+	return &c
+}
+
+// Violation is a request field that broke a validation rule
+type Violation struct {
+	// Field is the path of the field as the client sent it, e.g. availability[0].startTime
+	Field string
+	// Rule is the broken rule, e.g. required or min, and Param its argument
+	Rule  string
+	Param string
+	// Length is set when the rule limits the length of a text rather than its value
+	Length bool
+}
+
+func newError(kind Kind, code, title string) *Error {
+	return &Error{Kind: kind, Code: code, Title: title}
+}
+
+// Validation creates an error for a malformed or invalid request
+func Validation(code, title string) *Error { return newError(KindValidation, code, title) }
+
+// Unauthorized creates an error for a request without valid credentials
+func Unauthorized(code, title string) *Error { return newError(KindUnauthorized, code, title) }
+
+// Forbidden creates an error for a caller that may not perform the request
+func Forbidden(code, title string) *Error { return newError(KindForbidden, code, title) }
+
+// NotFound creates an error for a resource that does not exist
+func NotFound(code, title string) *Error { return newError(KindNotFound, code, title) }
+
+// Conflict creates an error for a request that clashes with the current state
+func Conflict(code, title string) *Error { return newError(KindConflict, code, title) }
+
+// RateLimited creates an error for a client that sent too many requests
+func RateLimited(code, title string) *Error { return newError(KindRateLimited, code, title) }
+
+// Unavailable creates an error for a dependency that cannot be reached
+func Unavailable(code, title string) *Error { return newError(KindUnavailable, code, title) }
+
+// ErrInternal is reported for errors that are not an *Error
+var ErrInternal = newError(KindInternal, "internal_error", "Internal server error")
+
+// As returns the *Error in err's chain, or ErrInternal wrapping err when there is none
+func As(err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	return ErrInternal.Wrap(err)
+}
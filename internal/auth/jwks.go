@@ -0,0 +1,255 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// minRefreshInterval throttles JWKS refetches triggered by unknown key IDs
+const minRefreshInterval = 30 * time.Second
+
+// KeySet holds the public keys of a JSON Web Key Set, keyed by key ID.
+// Keys fetched from a URL are cached and refreshed periodically, and on demand
+// when a token references a key ID that is not cached yet (key rotation).
+type KeySet struct {
+	url             string
+	client          *http.Client
+	refreshInterval time.Duration
+	minRefresh      time.Duration
+
+	// fetching is held by the one caller fetching the JWKS, so that concurrent callers
+	// share its result instead of fetching again
+	fetching sync.Mutex
+
+	mu          sync.RWMutex
+	keys        map[string]crypto.PublicKey
+	fetchedAt   time.Time
+	lastAttempt time.Time
+}
+
+// NewRemoteKeySet creates a KeySet that fetches the JWKS document from url
+func NewRemoteKeySet(url string, client *http.Client, refreshInterval time.Duration) *KeySet {
+	return &KeySet{
+		url:             url,
+		client:          client,
+		refreshInterval: refreshInterval,
+		minRefresh:      minRefreshInterval,
+	}
+}
+
+// LoadKeySetFile creates a static KeySet from a JWKS document on disk
+func LoadKeySetFile(path string) (*KeySet, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JWKS file: %w", err)
+	}
+	keys, err := parseJWKS(b)
+	if err != nil {
+		return nil, err
+	}
+	return &KeySet{keys: keys, fetchedAt: time.Now()}, nil
+}
+
+// Key returns the public key for kid. Stale keys are served while they are refreshed in
+// the background; only callers needing a key that is not cached wait for a fetch.
+func (s *KeySet) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	s.mu.RLock()
+	key, ok := s.keys[kid]
+	loaded := s.keys != nil
+	stale := time.Since(s.fetchedAt) > s.refreshInterval
+	s.mu.RUnlock()
+
+	if s.url == "" {
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown key id %q", ErrInvalidToken, kid)
+		}
+		return key, nil
+	}
+
+	if ok {
+		if stale && s.refreshDue() {
+			go s.refresh(context.WithoutCancel(ctx), false)
+		}
+		return key, nil
+	}
+
+	if err := s.refresh(ctx, true); err != nil && !loaded {
+		return nil, fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	s.mu.RLock()
+	key, ok = s.keys[kid]
+	loaded = s.keys != nil
+	s.mu.RUnlock()
+	if !ok {
+		if !loaded {
+			return nil, fmt.Errorf("%w: JWKS has not been loaded", ErrUnavailable)
+		}
+		return nil, fmt.Errorf("%w: unknown key id %q", ErrInvalidToken, kid)
+	}
+	return key, nil
+}
+
+// refreshDue reports whether the last fetch attempt is long enough ago to try again
+func (s *KeySet) refreshDue() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return time.Since(s.lastAttempt) > s.minRefresh
+}
+
+// refresh fetches the JWKS unless it was attempted too recently. With wait it waits for a
+// fetch already in progress, otherwise it leaves the fetch to that caller.
+func (s *KeySet) refresh(ctx context.Context, wait bool) error {
+	if wait {
+		s.fetching.Lock()
+	} else if !s.fetching.TryLock() {
+		return nil
+	}
+	defer s.fetching.Unlock()
+
+	// The fetch this caller waited for may have just happened
+	if !s.refreshDue() {
+		return nil
+	}
+
+	keys, err := s.fetch(ctx)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastAttempt = time.Now()
+	if err != nil {
+		// Keep serving the cached keys while the JWKS endpoint is down
+		return err
+	}
+	s.keys = keys
+	s.fetchedAt = s.lastAttempt
+	return nil
+}
+
+func (s *KeySet) fetch(ctx context.Context) (map[string]crypto.PublicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JWKS request: %w", err)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch JWKS: status %d", resp.StatusCode)
+	}
+	b, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JWKS: %w", err)
+	}
+	return parseJWKS(b)
+}
+
+type jwk struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// parseJWKS decodes the signing keys of a JWKS document, skipping unsupported key types
+func parseJWKS(b []byte) (map[string]crypto.PublicKey, error) {
+	var doc struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return nil, fmt.Errorf("failed to decode JWKS: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(doc.Keys))
+	for _, k := range doc.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("invalid JWK %q: %w", k.Kid, err)
+		}
+		if key != nil {
+			keys[k.Kid] = key
+		}
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("JWKS contains no usable signing keys")
+	}
+	return keys, nil
+}
+
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, nil
+	}
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) == 0 {
+		return nil, errors.New("invalid key parameter")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
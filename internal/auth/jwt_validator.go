@@ -0,0 +1,49 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// clockSkew tolerates small clock differences between this service and the token issuer
+const clockSkew = 30 * time.Second
+
+// jwtValidator verifies JWT signatures locally against a JWKS and checks the standard claims
+type jwtValidator struct {
+	keys   *KeySet
+	parser *jwt.Parser
+}
+
+// NewJWTValidator creates a new jwtValidator. Empty issuer or audience disables that check.
+func NewJWTValidator(keys *KeySet, issuer, audience string) Validator {
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(clockSkew),
+	}
+	if issuer != "" {
+		opts = append(opts, jwt.WithIssuer(issuer))
+	}
+	if audience != "" {
+		opts = append(opts, jwt.WithAudience(audience))
+	}
+	return &jwtValidator{keys: keys, parser: jwt.NewParser(opts...)}
+}
+
+func (v *jwtValidator) Validate(ctx context.Context, token string) (*Principal, error) {
+	claims := jwt.MapClaims{}
+	_, err := v.parser.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return v.keys.Key(ctx, kid)
+	})
+	if err != nil {
+		if isUnavailable(err) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	return principalFromClaims(claims)
+}
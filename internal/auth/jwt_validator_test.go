@@ -0,0 +1,226 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// jwksServer serves the public keys of its signing keys as a JWKS document
+type jwksServer struct {
+	*httptest.Server
+	fetches atomic.Int32
+	delay   time.Duration
+
+	mu   sync.Mutex
+	keys map[string]*rsa.PrivateKey
+}
+
+func newJWKSServer(t *testing.T, kids ...string) *jwksServer {
+	s := &jwksServer{keys: map[string]*rsa.PrivateKey{}}
+	for _, kid := range kids {
+		s.addKey(t, kid)
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.fetches.Add(1)
+		time.Sleep(s.delay)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		var doc struct {
+			Keys []jwk `json:"keys"`
+		}
+		for kid, key := range s.keys {
+			doc.Keys = append(doc.Keys, jwk{
+				Kid: kid,
+				Kty: "RSA",
+				N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			})
+		}
+		json.NewEncoder(w).Encode(doc)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *jwksServer) addKey(t *testing.T, kid string) *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys[kid] = key
+	return key
+}
+
+func (s *jwksServer) key(kid string) *rsa.PrivateKey {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.keys[kid]
+}
+
+func sign(t *testing.T, key *rsa.PrivateKey, kid string, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+	return signed
+}
+
+func validClaims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"sub":       "user-1",
+		"iss":       "https://auth.example.com",
+		"aud":       "product",
+		"exp":       time.Now().Add(time.Hour).Unix(),
+		"roles":     []string{"barista"},
+		"tenant_id": "kopi",
+	}
+}
+
+func TestJWTValidator(t *testing.T) {
+	srv := newJWKSServer(t, "k1")
+	keys := NewRemoteKeySet(srv.URL, srv.Client(), time.Hour)
+	v := NewJWTValidator(keys, "https://auth.example.com", "product")
+
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	with := func(change func(c jwt.MapClaims)) jwt.MapClaims {
+		c := validClaims()
+		change(c)
+		return c
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		want  error
+	}{
+		{"valid", sign(t, srv.key("k1"), "k1", validClaims()), nil},
+		{"signed by another key", sign(t, other, "k1", validClaims()), ErrInvalidToken},
+		{"wrong issuer", sign(t, srv.key("k1"), "k1", with(func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" })), ErrInvalidToken},
+		{"wrong audience", sign(t, srv.key("k1"), "k1", with(func(c jwt.MapClaims) { c["aud"] = "billing" })), ErrInvalidToken},
+		{"expired", sign(t, srv.key("k1"), "k1", with(func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Hour).Unix() })), ErrInvalidToken},
+		{"expired within clock skew", sign(t, srv.key("k1"), "k1", with(func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-clockSkew / 2).Unix() })), nil},
+		{"without exp", sign(t, srv.key("k1"), "k1", with(func(c jwt.MapClaims) { delete(c, "exp") })), ErrInvalidToken},
+		{"without subject", sign(t, srv.key("k1"), "k1", with(func(c jwt.MapClaims) { delete(c, "sub") })), ErrInvalidToken},
+		{"malformed", "not.a.jwt", ErrInvalidToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := v.Validate(context.Background(), tt.token)
+			if tt.want != nil {
+				if !errors.Is(err, tt.want) {
+					t.Fatalf("Validate() error = %v, want %v", err, tt.want)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Validate() error = %v", err)
+			}
+			if p.UserID != "user-1" || p.TenantID != "kopi" || !p.HasRole("barista") {
+				t.Errorf("Validate() = %+v, want the claims of the token", p)
+			}
+		})
+	}
+}
+
+func TestKeySetRefreshesOnUnknownKid(t *testing.T) {
+	srv := newJWKSServer(t, "k1")
+	keys := NewRemoteKeySet(srv.URL, srv.Client(), time.Hour)
+	keys.minRefresh = 0
+	v := NewJWTValidator(keys, "", "")
+
+	if _, err := v.Validate(context.Background(), sign(t, srv.key("k1"), "k1", validClaims())); err != nil {
+		t.Fatalf("Validate(k1) error = %v", err)
+	}
+
+	// The issuer rotates to a new key, which is fetched on first use
+	rotated := srv.addKey(t, "k2")
+	if _, err := v.Validate(context.Background(), sign(t, rotated, "k2", validClaims())); err != nil {
+		t.Fatalf("Validate(k2) after rotation error = %v", err)
+	}
+	if got := srv.fetches.Load(); got != 2 {
+		t.Errorf("JWKS fetched %d times, want 2", got)
+	}
+
+	if _, err := v.Validate(context.Background(), sign(t, rotated, "unknown", validClaims())); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Validate(unknown kid) error = %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestKeySetThrottlesRefreshes(t *testing.T) {
+	srv := newJWKSServer(t, "k1")
+	keys := NewRemoteKeySet(srv.URL, srv.Client(), time.Hour)
+
+	for i := 0; i < 5; i++ {
+		if _, err := keys.Key(context.Background(), "unknown"); !errors.Is(err, ErrInvalidToken) {
+			t.Fatalf("Key(unknown) error = %v, want ErrInvalidToken", err)
+		}
+	}
+	if got := srv.fetches.Load(); got != 1 {
+		t.Errorf("JWKS fetched %d times for unknown key IDs, want 1", got)
+	}
+}
+
+func TestKeySetServesStaleKeysWhileRefreshing(t *testing.T) {
+	srv := newJWKSServer(t, "k1")
+	keys := NewRemoteKeySet(srv.URL, srv.Client(), time.Hour)
+	keys.minRefresh = 0
+	if _, err := keys.Key(context.Background(), "k1"); err != nil {
+		t.Fatalf("Key(k1) error = %v", err)
+	}
+
+	// Every cached key is now stale and the JWKS endpoint slow
+	keys.refreshInterval = 0
+	srv.delay = 500 * time.Millisecond
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := keys.Key(context.Background(), "k1"); err != nil {
+				t.Errorf("Key(k1) error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if elapsed := time.Since(start); elapsed > srv.delay/2 {
+		t.Errorf("Key waited %s for the refresh, want the cached key at once", elapsed)
+	}
+
+	time.Sleep(srv.delay + 100*time.Millisecond)
+	if got := srv.fetches.Load(); got != 2 {
+		t.Errorf("JWKS fetched %d times, want one background refresh", got)
+	}
+}
+
+func TestKeySetUnavailable(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+	keys := NewRemoteKeySet(srv.URL, srv.Client(), time.Hour)
+
+	if _, err := keys.Key(context.Background(), "k1"); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("Key() with the JWKS endpoint down error = %v, want ErrUnavailable", err)
+	}
+}
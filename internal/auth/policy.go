@@ -0,0 +1,106 @@
+package auth
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Permission is an action a principal may be granted through its roles
+type Permission string
+
+const (
+	PermProductRead   Permission = "product:read"
+	PermProductWrite  Permission = "product:write"
+	PermProductDelete Permission = "product:delete"
+	// PermProductPublish approves, publishes, archives and restores products
+	PermProductPublish Permission = "product:publish"
+	PermStockAdjust    Permission = "stock:adjust"
+	PermOutletWrite    Permission = "outlet:write"
+	// PermTenantAny lets a principal that is not bound to a tenant act on any tenant with the
+	// X-Tenant-ID header. Being cross-tenant, it is only granted explicitly, not by PermAll.
+	PermTenantAny Permission = "tenant:any"
+
+	// PermAll grants every permission
+	PermAll Permission = "*"
+)
+
+// Policy maps roles to the permissions they grant
+type Policy struct {
+	roles map[string]map[Permission]bool
+}
+
+// NewPolicy creates a Policy from a role to permissions mapping. Role names are case-insensitive.
+func NewPolicy(roles map[string][]Permission) *Policy {
+	p := &Policy{roles: make(map[string]map[Permission]bool, len(roles))}
+	for role, perms := range roles {
+		set := make(map[Permission]bool, len(perms))
+		for _, perm := range perms {
+			set[perm] = true
+		}
+		p.roles[strings.ToLower(role)] = set
+	}
+	return p
+}
+
+// DefaultPolicy is used when no role mapping is configured
+func DefaultPolicy() *Policy {
+	return NewPolicy(map[string][]Permission{
+		"admin":    {PermAll},
+		"owner":    {PermAll},
+		"manager":  {PermProductRead, PermProductWrite, PermProductDelete, PermProductPublish, PermStockAdjust, PermOutletWrite},
+		"barista":  {PermProductRead, PermProductWrite, PermStockAdjust},
+		"staff":    {PermProductRead, PermStockAdjust},
+		"customer": {PermProductRead},
+	})
+}
+
+// ParsePolicy parses a mapping in the form "role=perm,perm;role=perm",
+// e.g. "admin=*;barista=product:read,stock:adjust"
+func ParsePolicy(s string) (*Policy, error) {
+	roles := map[string][]Permission{}
+	for _, entry := range strings.Split(s, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		role, perms, ok := strings.Cut(entry, "=")
+		role = strings.TrimSpace(role)
+		if !ok || role == "" {
+			return nil, fmt.Errorf("invalid role mapping %q, expected role=perm,perm", entry)
+		}
+		for _, perm := range strings.Split(perms, ",") {
+			if perm = strings.TrimSpace(perm); perm != "" {
+				roles[role] = append(roles[role], Permission(perm))
+			}
+		}
+	}
+	if len(roles) == 0 {
+		return nil, fmt.Errorf("role mapping is empty")
+	}
+	return NewPolicy(roles), nil
+}
+
+// Allows reports whether any role of principal grants perm
+func (p *Policy) Allows(principal *Principal, perm Permission) bool {
+	if principal == nil {
+		return false
+	}
+	for _, role := range principal.Roles {
+		set := p.roles[strings.ToLower(role)]
+		if set[perm] || (set[PermAll] && perm != PermTenantAny) {
+			return true
+		}
+	}
+	return false
+}
+
+// Roles returns the configured role names, sorted
+func (p *Policy) Roles() []string {
+	roles := make([]string, 0, len(p.roles))
+	for role := range p.roles {
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+	return roles
+}
@@ -0,0 +1,88 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Principal is the authenticated caller of a request
+type Principal struct {
+	UserID   string   `json:"userId"`
+	Name     string   `json:"name"`
+	Roles    []string `json:"roles"`
+	OutletID string   `json:"outletId"`
+	TenantID string   `json:"tenantId"`
+}
+
+// HasRole reports whether the principal has role
+func (p *Principal) HasRole(role string) bool {
+	for _, r := range p.Roles {
+		if strings.EqualFold(r, role) {
+			return true
+		}
+	}
+	return false
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying p
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFromContext returns the principal stored in ctx, or nil for anonymous requests
+func PrincipalFromContext(ctx context.Context) *Principal {
+	p, _ := ctx.Value(principalKey{}).(*Principal)
+	return p
+}
+
+// principalFromClaims maps JWT claims, or the user object returned by the auth service,
+// onto a Principal. Both camelCase and snake_case spellings are accepted.
+func principalFromClaims(claims map[string]interface{}) (*Principal, error) {
+	p := &Principal{
+		UserID:   firstString(claims, "sub", "user_id", "userId", "id"),
+		Name:     firstString(claims, "name", "username", "preferred_username", "email"),
+		Roles:    stringList(claims, "roles", "role"),
+		OutletID: firstString(claims, "outlet_id", "outletId", "outlet"),
+		TenantID: firstString(claims, "tenant_id", "tenantId", "tenant", "merchant_id", "merchantId"),
+	}
+	if p.UserID == "" {
+		return nil, fmt.Errorf("%w: no user id in token claims", ErrInvalidToken)
+	}
+	return p, nil
+}
+
+func firstString(claims map[string]interface{}, keys ...string) string {
+	for _, k := range keys {
+		switch v := claims[k].(type) {
+		case string:
+			if v != "" {
+				return v
+			}
+		case float64:
+			return fmt.Sprintf("%.0f", v)
+		}
+	}
+	return ""
+}
+
+// stringList reads a claim that may be a JSON array or a space/comma separated string
+func stringList(claims map[string]interface{}, keys ...string) []string {
+	for _, k := range keys {
+		switch v := claims[k].(type) {
+		case []interface{}:
+			out := make([]string, 0, len(v))
+			for _, item := range v {
+				if s, ok := item.(string); ok && s != "" {
+					out = append(out, s)
+				}
+			}
+			return out
+		case string:
+			return strings.FieldsFunc(v, func(r rune) bool { return r == ' ' || r == ',' })
+		}
+	}
+	return nil
+}
@@ -0,0 +1,183 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/dominikuswilly/nofu-be_product/internal/logging"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// NewHTTPClient creates the HTTP client shared by all outgoing auth calls, bounded by timeout.
+// Every call is traced and carries the W3C trace context of the request it is made for.
+func NewHTTPClient(timeout time.Duration) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{Timeout: timeout, KeepAlive: 30 * time.Second}).DialContext
+	transport.TLSHandshakeTimeout = timeout
+	transport.ResponseHeaderTimeout = timeout
+	transport.MaxIdleConnsPerHost = 32
+
+	return &http.Client{Timeout: timeout, Transport: otelhttp.NewTransport(transport)}
+}
+
+// RemoteOptions tunes caching and circuit breaking of the remote validator
+type RemoteOptions struct {
+	// CacheTTL is how long a validated token is trusted without asking again; zero disables caching
+	CacheTTL  time.Duration
+	CacheSize int
+	// BreakerThreshold consecutive failures open the breaker for BreakerCooldown
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+	// Stats receives cache and breaker metrics, if set
+	Stats *RemoteStats
+}
+
+// RemoteStats collects cache and circuit breaker metrics of the remote validator
+type RemoteStats struct {
+	CacheHits    atomic.Int64
+	CacheMisses  atomic.Int64
+	Failures     atomic.Int64
+	Rejected     atomic.Int64
+	BreakerOpens atomic.Int64
+	breakerState atomic.Int32
+}
+
+// BreakerState returns the current circuit breaker state
+func (s *RemoteStats) BreakerState() BreakerState {
+	return BreakerState(s.breakerState.Load())
+}
+
+// remoteValidator validates tokens by calling the auth service validate endpoint
+type remoteValidator struct {
+	url     string
+	client  *http.Client
+	cache   *tokenCache
+	breaker *breaker
+	stats   *RemoteStats
+}
+
+// NewRemoteValidator creates a new remoteValidator
+func NewRemoteValidator(url string, client *http.Client, opts RemoteOptions) Validator {
+	stats := opts.Stats
+	if stats == nil {
+		stats = &RemoteStats{}
+	}
+
+	v := &remoteValidator{url: url, client: client, stats: stats}
+	if opts.CacheTTL > 0 {
+		v.cache = newTokenCache(opts.CacheTTL, opts.CacheSize)
+	}
+	if opts.BreakerThreshold > 0 {
+		v.breaker = newBreaker(opts.BreakerThreshold, opts.BreakerCooldown, func(s BreakerState) {
+			stats.breakerState.Store(int32(s))
+			if s == BreakerOpen {
+				stats.BreakerOpens.Add(1)
+			}
+		})
+	}
+	return v
+}
+
+func (v *remoteValidator) Validate(ctx context.Context, token string) (*Principal, error) {
+	if v.cache != nil {
+		if p := v.cache.get(token); p != nil {
+			v.stats.CacheHits.Add(1)
+			return p, nil
+		}
+		v.stats.CacheMisses.Add(1)
+	}
+
+	if v.breaker != nil && !v.breaker.allow() {
+		v.stats.Rejected.Add(1)
+		return nil, fmt.Errorf("%w: circuit breaker is open", ErrUnavailable)
+	}
+
+	p, err := v.call(ctx, token)
+	// Only outages count against the breaker, a rejected token is a healthy answer
+	if v.breaker != nil {
+		v.breaker.done(err == nil || !isUnavailable(err))
+	}
+	if err != nil {
+		if isUnavailable(err) {
+			v.stats.Failures.Add(1)
+		}
+		return nil, err
+	}
+
+	if v.cache != nil {
+		v.cache.add(token, p)
+	}
+	return p, nil
+}
+
+func (v *remoteValidator) call(ctx context.Context, token string) (*Principal, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, v.url, bytes.NewBuffer([]byte("{}")))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create validate request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	// Let the auth service correlate its logs with ours
+	if id := logging.RequestIDFromContext(ctx); id != "" {
+		req.Header.Set(logging.RequestIDHeader, id)
+	}
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return nil, fmt.Errorf("%w: auth service returned %d", ErrUnavailable, resp.StatusCode)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: auth service returned %d", ErrInvalidToken, resp.StatusCode)
+	}
+
+	var body map[string]interface{}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body); err != nil {
+		return nil, fmt.Errorf("%w: failed to decode validate response: %v", ErrUnavailable, err)
+	}
+	return principalFromClaims(userClaims(body))
+}
+
+// userClaims locates the user object in the validate response. It is usually wrapped in
+// the standard data envelope, optionally under a "user" key.
+func userClaims(body map[string]interface{}) map[string]interface{} {
+	if data, ok := body["data"].(map[string]interface{}); ok {
+		body = data
+	}
+	if user, ok := body["user"].(map[string]interface{}); ok {
+		return user
+	}
+	return body
+}
+
+// CheckReachable reports whether the service at url answers. Any response below 500 counts,
+// since the endpoint may well reject a request without a token.
+func CheckReachable(ctx context.Context, client *http.Client, url string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("%s returned %d", url, resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package auth
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// tokenCache remembers recently validated tokens for a short TTL. Tokens are keyed by
+// their SHA-256 hash so raw credentials are never kept in memory longer than needed.
+// When full, the least recently used token is evicted.
+type tokenCache struct {
+	ttl     time.Duration
+	maxSize int
+
+	mu      sync.Mutex
+	entries map[[sha256.Size]byte]*list.Element
+	// lru orders the entries from most to least recently used
+	lru *list.List
+}
+
+type cachedToken struct {
+	key       [sha256.Size]byte
+	principal *Principal
+	expiresAt time.Time
+}
+
+func newTokenCache(ttl time.Duration, maxSize int) *tokenCache {
+	return &tokenCache{
+		ttl:     ttl,
+		maxSize: maxSize,
+		entries: make(map[[sha256.Size]byte]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+func (c *tokenCache) get(token string) *Principal {
+	key := sha256.Sum256([]byte(token))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		return nil
+	}
+	entry := el.Value.(*cachedToken)
+	if time.Now().After(entry.expiresAt) {
+		c.remove(el)
+		return nil
+	}
+	c.lru.MoveToFront(el)
+	return entry.principal
+}
+
+func (c *tokenCache) add(token string, p *Principal) {
+	key := sha256.Sum256([]byte(token))
+	expiresAt := time.Now().Add(c.ttl)
+	// A token is never trusted beyond its own expiry
+	if exp, ok := tokenExpiry(token); ok && exp.Before(expiresAt) {
+		expiresAt = exp
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.entries[key]; ok {
+		c.remove(el)
+	}
+	for len(c.entries) >= c.maxSize {
+		c.remove(c.lru.Back())
+	}
+	c.entries[key] = c.lru.PushFront(&cachedToken{key: key, principal: p, expiresAt: expiresAt})
+}
+
+func (c *tokenCache) remove(el *list.Element) {
+	c.lru.Remove(el)
+	delete(c.entries, el.Value.(*cachedToken).key)
+}
+
+// tokenExpiry reads the exp claim of token if it is a JWT. The signature is not checked,
+// the expiry only ever shortens how long the token is cached.
+func tokenExpiry(token string) (time.Time, bool) {
+	claims := jwt.MapClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(token, claims); err != nil {
+		return time.Time{}, false
+	}
+	exp, err := claims.GetExpirationTime()
+	if err != nil || exp == nil {
+		return time.Time{}, false
+	}
+	return exp.Time, true
+}
@@ -0,0 +1,46 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestTokenCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	c := newTokenCache(time.Minute, 2)
+	c.add("a", &Principal{UserID: "a"})
+	c.add("b", &Principal{UserID: "b"})
+	c.get("a")
+	c.add("c", &Principal{UserID: "c"})
+
+	if c.get("b") != nil {
+		t.Error("b is still cached, want it evicted as least recently used")
+	}
+	for _, token := range []string{"a", "c"} {
+		if c.get(token) == nil {
+			t.Errorf("%s was evicted, want only b evicted", token)
+		}
+	}
+}
+
+func TestTokenCacheHonoursTokenExpiry(t *testing.T) {
+	c := newTokenCache(time.Hour, 10)
+
+	expiring, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub": "user-1",
+		"exp": time.Now().Add(-time.Second).Unix(),
+	}).SignedString([]byte("secret"))
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+	c.add(expiring, &Principal{UserID: "user-1"})
+	if c.get(expiring) != nil {
+		t.Error("an expired token is served from the cache")
+	}
+
+	c.add("opaque", &Principal{UserID: "user-2"})
+	if c.get("opaque") == nil {
+		t.Error("an opaque token is not cached for the TTL")
+	}
+}
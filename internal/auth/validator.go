@@ -0,0 +1,41 @@
+package auth
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	// ErrInvalidToken is returned when a token is malformed, expired or rejected
+	ErrInvalidToken = errors.New("invalid token")
+	// ErrUnavailable is returned when a token cannot be checked, e.g. the auth service or JWKS is unreachable
+	ErrUnavailable = errors.New("token verification unavailable")
+)
+
+// Validator verifies bearer tokens and resolves the principal they identify
+type Validator interface {
+	Validate(ctx context.Context, token string) (*Principal, error)
+}
+
+// fallbackValidator verifies tokens with primary and only consults secondary when primary is unavailable
+type fallbackValidator struct {
+	primary   Validator
+	secondary Validator
+}
+
+// NewFallbackValidator creates a Validator that falls back to secondary when primary returns ErrUnavailable
+func NewFallbackValidator(primary, secondary Validator) Validator {
+	return &fallbackValidator{primary: primary, secondary: secondary}
+}
+
+func (v *fallbackValidator) Validate(ctx context.Context, token string) (*Principal, error) {
+	p, err := v.primary.Validate(ctx, token)
+	if isUnavailable(err) {
+		return v.secondary.Validate(ctx, token)
+	}
+	return p, err
+}
+
+func isUnavailable(err error) bool {
+	return errors.Is(err, ErrUnavailable)
+}
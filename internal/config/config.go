@@ -1,44 +1,272 @@
+// Package config loads the service configuration from defaults, an optional YAML or TOML
+// file and environment variables, and validates it before the service starts.
 package config
 
-import (
-	"log"
-	"os"
+import "time"
 
-	"github.com/joho/godotenv"
-)
-
-// Config holds the application configuration
+// Config holds the application configuration. Every setting has a file key, e.g.
+// db.max_open_conns, and an environment variable, e.g. DB_MAX_OPEN_CONNS, which wins.
 type Config struct {
-	AppPort        string
-	DBHost         string
-	DBPort         string
-	DBUser         string
-	DBPassword     string
-	DBName         string
-	AuthServiceURL string
-}
-
-// Load loads configuration from environment variables
-func Load() *Config {
-	// Load .env file if it exists
-	if err := godotenv.Load(); err != nil {
-		log.Println("No .env file found, using system environment variables")
-	}
+	Server    ServerConfig    `conf:"server"`
+	Log       LogConfig       `conf:"log"`
+	Storage   StorageConfig   `conf:"storage"`
+	DB        DBConfig        `conf:"db"`
+	Media     MediaConfig     `conf:"media"`
+	Auth      AuthConfig      `conf:"auth"`
+	CORS      CORSConfig      `conf:"cors"`
+	Catalog   CatalogConfig   `conf:"catalog"`
+	RateLimit RateLimitConfig `conf:"rate_limit"`
+	Tenant    TenantConfig    `conf:"tenant"`
+	Tracing   TracingConfig   `conf:"tracing"`
+	Metrics   MetricsConfig   `conf:"metrics"`
+}
 
-	return &Config{
-		AppPort:        getEnv("APP_PORT", "8080"),
-		DBHost:         getEnv("DB_HOST", "localhost"),
-		DBPort:         getEnv("DB_PORT", "5432"),
-		DBUser:         getEnv("DB_USER", "postgres"),
-		DBPassword:     getEnv("DB_PASS", "postgres"), // Changed to DB_PASS as requested
-		DBName:         getEnv("DB_NAME", "postgres"),
-		AuthServiceURL: getEnv("AUTH_SERVICE_URL", "https://apinofudev.bengkelfajarjaya.com/api/customer/auth/validate"),
-	}
+// ServerConfig tunes the HTTP server and its probes
+type ServerConfig struct {
+	Port              int           `conf:"port" env:"APP_PORT"`
+	ReadHeaderTimeout time.Duration `conf:"read_header_timeout" env:"SERVER_READ_HEADER_TIMEOUT"`
+	// ReadTimeout bounds reading a whole request, including image uploads
+	ReadTimeout  time.Duration `conf:"read_timeout" env:"SERVER_READ_TIMEOUT"`
+	WriteTimeout time.Duration `conf:"write_timeout" env:"SERVER_WRITE_TIMEOUT"`
+	IdleTimeout  time.Duration `conf:"idle_timeout" env:"SERVER_IDLE_TIMEOUT"`
+	// ShutdownTimeout bounds draining open connections on shutdown
+	ShutdownTimeout time.Duration `conf:"shutdown_timeout" env:"SERVER_SHUTDOWN_TIMEOUT"`
+	// ShutdownDelay is how long the service keeps serving while reporting not ready before it
+	// drains connections, giving load balancers time to take it out of rotation
+	ShutdownDelay time.Duration `conf:"shutdown_delay" env:"SHUTDOWN_DELAY"`
+	// ReadyTimeout bounds the dependency checks of the readiness probe
+	ReadyTimeout time.Duration `conf:"ready_timeout" env:"READY_TIMEOUT"`
+	// ReadyCheckAuth makes readiness depend on the auth service being reachable
+	ReadyCheckAuth bool `conf:"ready_check_auth" env:"READY_CHECK_AUTH"`
+	// TrustedProxies lists the IPs and CIDRs of proxies whose X-Forwarded-For names the
+	// client IP. Empty trusts none and uses the connection's address.
+	TrustedProxies []string `conf:"trusted_proxies" env:"TRUSTED_PROXIES"`
+}
+
+// LogConfig configures logging
+type LogConfig struct {
+	// Level is debug, info, warn or error
+	Level string `conf:"level" env:"LOG_LEVEL"`
+}
+
+// StorageConfig selects the repository backend
+type StorageConfig struct {
+	// Backend is "postgres", "sqlite" or "memory". Memory keeps everything in process and
+	// loses it on restart; it is meant for local frontend work.
+	Backend string `conf:"backend" env:"STORAGE"`
+	// SQLitePath is the database file used by the sqlite backend
+	SQLitePath string `conf:"sqlite_path" env:"SQLITE_PATH"`
+}
+
+// DBConfig configures the PostgreSQL connection
+type DBConfig struct {
+	Host     string `conf:"host" env:"DB_HOST"`
+	Port     int    `conf:"port" env:"DB_PORT"`
+	User     string `conf:"user" env:"DB_USER"`
+	Password string `conf:"password" env:"DB_PASS" secret:"true"`
+	Name     string `conf:"name" env:"DB_NAME"`
+	// SSLMode is disable, require (encrypted, server not verified), verify-ca (server
+	// certificate signed by a trusted CA) or verify-full (verify-ca plus matching host name)
+	SSLMode string `conf:"sslmode" env:"DB_SSLMODE"`
+	// SSLRootCert is the CA certificate file servers are verified against; empty uses the
+	// system CAs
+	SSLRootCert string `conf:"sslrootcert" env:"DB_SSLROOTCERT"`
+	// SSLCert and SSLKey are the client certificate and key files for certificate
+	// authentication
+	SSLCert string `conf:"sslcert" env:"DB_SSLCERT"`
+	SSLKey  string `conf:"sslkey" env:"DB_SSLKEY"`
+	// MaxOpenConns limits the connections of the pool; 0 means unlimited
+	MaxOpenConns int `conf:"max_open_conns" env:"DB_MAX_OPEN_CONNS"`
+	MaxIdleConns int `conf:"max_idle_conns" env:"DB_MAX_IDLE_CONNS"`
+	// ConnMaxLifetime and ConnMaxIdleTime close connections that are this old or have been
+	// idle this long, so failovers and load balancers are picked up; 0 keeps them forever
+	ConnMaxLifetime time.Duration `conf:"conn_max_lifetime" env:"DB_CONN_MAX_LIFETIME"`
+	ConnMaxIdleTime time.Duration `conf:"conn_max_idle_time" env:"DB_CONN_MAX_IDLE_TIME"`
+	// ConnectTimeout is how long startup waits for the database to become reachable,
+	// retrying with exponential backoff
+	ConnectTimeout time.Duration `conf:"connect_timeout" env:"DB_CONNECT_TIMEOUT"`
+	// QueryTimeout bounds every repository call; 0 disables the limit
+	QueryTimeout time.Duration `conf:"query_timeout" env:"DB_QUERY_TIMEOUT"`
+	// MigrateOnStart applies pending schema migrations before serving
+	MigrateOnStart bool `conf:"migrate_on_start" env:"DB_MIGRATE_ON_START"`
+}
+
+// MediaConfig configures where uploaded images are stored and served from
+type MediaConfig struct {
+	Dir     string `conf:"dir" env:"MEDIA_DIR"`
+	BaseURL string `conf:"base_url" env:"MEDIA_BASE_URL"`
+	// MaxImagePixels caps width × height of uploaded images, which are decoded in memory
+	MaxImagePixels int `conf:"max_image_pixels" env:"MEDIA_MAX_IMAGE_PIXELS"`
+}
+
+// AuthConfig configures token validation and authorization
+type AuthConfig struct {
+	// Mode selects how bearer tokens are verified: "jwt" (local JWKS), "remote" (auth
+	// service validate endpoint) or "jwt+remote" (local, falling back to the auth service
+	// when the JWKS is unavailable)
+	Mode        string        `conf:"mode" env:"AUTH_MODE"`
+	ServiceURL  string        `conf:"service_url" env:"AUTH_SERVICE_URL"`
+	JWKSURL     string        `conf:"jwks_url" env:"AUTH_JWKS_URL"`
+	JWKSFile    string        `conf:"jwks_file" env:"AUTH_JWKS_FILE"`
+	JWKSRefresh time.Duration `conf:"jwks_refresh" env:"AUTH_JWKS_REFRESH"`
+	JWTIssuer   string        `conf:"jwt_issuer" env:"AUTH_JWT_ISSUER"`
+	JWTAudience string        `conf:"jwt_audience" env:"AUTH_JWT_AUDIENCE"`
+
+	HTTPTimeout time.Duration `conf:"http_timeout" env:"AUTH_HTTP_TIMEOUT"`
+	// CacheTTL is how long a validated token is trusted; 0 disables caching
+	CacheTTL        time.Duration `conf:"cache_ttl" env:"AUTH_CACHE_TTL"`
+	CacheSize       int           `conf:"cache_size" env:"AUTH_CACHE_SIZE"`
+	BreakerFailures int           `conf:"breaker_failures" env:"AUTH_BREAKER_FAILURES"`
+	BreakerCooldown time.Duration `conf:"breaker_cooldown" env:"AUTH_BREAKER_COOLDOWN"`
+
+	// RBACPolicy maps roles to permissions as "role=perm,perm;role=perm".
+	// Empty uses the built-in defaults.
+	RBACPolicy string `conf:"rbac_policy" env:"RBAC_POLICY"`
+}
+
+// CORSConfig configures which browser origins may call the API
+type CORSConfig struct {
+	// AllowedOrigins lists origins such as https://kopinofu.com, patterns such as
+	// https://*.kopinofu.com matching any subdomain, or * for any origin
+	AllowedOrigins []string `conf:"allowed_origins" env:"CORS_ALLOWED_ORIGINS"`
+	AllowedMethods []string `conf:"allowed_methods" env:"CORS_ALLOWED_METHODS"`
+	AllowedHeaders []string `conf:"allowed_headers" env:"CORS_ALLOWED_HEADERS"`
+	// ExposedHeaders are the response headers browser scripts may read
+	ExposedHeaders []string `conf:"exposed_headers" env:"CORS_EXPOSED_HEADERS"`
+	// AllowCredentials lets browsers send cookies and read responses to credentialed requests
+	AllowCredentials bool `conf:"allow_credentials" env:"CORS_ALLOW_CREDENTIALS"`
+	// MaxAge is how long browsers may cache a preflight response
+	MaxAge time.Duration `conf:"max_age" env:"CORS_MAX_AGE"`
+}
+
+// CatalogConfig tunes the public catalog: HTTP cache lifetime and per-IP rate limit
+type CatalogConfig struct {
+	CacheMaxAge time.Duration `conf:"cache_max_age" env:"CATALOG_CACHE_MAX_AGE"`
+	RateLimit   float64       `conf:"rate_limit" env:"CATALOG_RATE_LIMIT"`
+	RateBurst   int           `conf:"rate_burst" env:"CATALOG_RATE_BURST"`
+}
+
+// RateLimitConfig configures the token bucket rate limits of the authenticated API. The
+// catalog is limited by CatalogConfig.
+type RateLimitConfig struct {
+	Enabled bool `conf:"enabled" env:"RATE_LIMIT_ENABLED"`
+	// Store is "memory" for limits per instance or "redis" for limits shared by the cluster
+	Store string `conf:"store" env:"RATE_LIMIT_STORE"`
+	// RedisURL is e.g. redis://:password@localhost:6379/0, or rediss:// for TLS
+	RedisURL    string `conf:"redis_url" env:"RATE_LIMIT_REDIS_URL" secret:"true"`
+	RedisPrefix string `conf:"redis_prefix" env:"RATE_LIMIT_REDIS_PREFIX"`
+	// AuthRate and AuthBurst limit each client IP before its token is validated
+	AuthRate  float64 `conf:"auth_rate" env:"RATE_LIMIT_AUTH_RATE"`
+	AuthBurst int     `conf:"auth_burst" env:"RATE_LIMIT_AUTH_BURST"`
+	// ReadRate and ReadBurst limit each user's GET requests
+	ReadRate  float64 `conf:"read_rate" env:"RATE_LIMIT_READ_RATE"`
+	ReadBurst int     `conf:"read_burst" env:"RATE_LIMIT_READ_BURST"`
+	// WriteRate and WriteBurst limit each user's other requests
+	WriteRate  float64 `conf:"write_rate" env:"RATE_LIMIT_WRITE_RATE"`
+	WriteBurst int     `conf:"write_burst" env:"RATE_LIMIT_WRITE_BURST"`
+	// APIKeys are the X-API-Key values of clients limited on their own rather than by IP
+	APIKeys []string `conf:"api_keys" env:"RATE_LIMIT_API_KEYS" secret:"true"`
+}
+
+// TenantConfig holds the defaults of tenants
+type TenantConfig struct {
+	// DefaultID is used when neither the token nor the X-Tenant-ID header names a tenant.
+	// Empty makes the tenant mandatory.
+	DefaultID string `conf:"default_id" env:"DEFAULT_TENANT_ID"`
+	// DefaultTimezone is the IANA time zone used to evaluate availability schedules
+	// for outlets without their own time zone
+	DefaultTimezone string `conf:"default_timezone" env:"DEFAULT_TIMEZONE"`
+}
+
+// TracingConfig selects where spans go
+type TracingConfig struct {
+	// Exporter is "none", "stdout" or "otlp" (configured with the standard
+	// OTEL_EXPORTER_OTLP_* variables)
+	Exporter    string `conf:"exporter" env:"TRACING_EXPORTER"`
+	ServiceName string `conf:"service_name" env:"OTEL_SERVICE_NAME"`
+	// SampleRatio is the fraction of new traces that is recorded
+	SampleRatio float64 `conf:"sample_ratio" env:"TRACING_SAMPLE_RATIO"`
+}
+
+// MetricsConfig configures the metrics endpoint and tunes the business metrics
+type MetricsConfig struct {
+	// Port serves /metrics apart from the API, so it can be kept off the public network;
+	// 0 disables the endpoint
+	Port int `conf:"port" env:"METRICS_PORT"`
+	// ProductsCacheTTL is how long the product counts are reused across scrapes
+	ProductsCacheTTL time.Duration `conf:"products_cache_ttl" env:"METRICS_PRODUCTS_CACHE_TTL"`
+	// LowStockThreshold is the stock at or below which a published product counts as low on
+	// stock
+	LowStockThreshold int `conf:"low_stock_threshold" env:"LOW_STOCK_THRESHOLD"`
 }
 
-func getEnv(key, fallback string) string {
-	if value, exists := os.LookupEnv(key); exists {
-		return value
+// Default returns the configuration used for settings that are not configured
+func Default() Config {
+	return Config{
+		Server: ServerConfig{
+			Port:              8080,
+			ReadHeaderTimeout: 10 * time.Second,
+			ReadTimeout:       time.Minute,
+			WriteTimeout:      time.Minute,
+			IdleTimeout:       2 * time.Minute,
+			ShutdownTimeout:   5 * time.Second,
+			ShutdownDelay:     5 * time.Second,
+			ReadyTimeout:      2 * time.Second,
+		},
+		Log:     LogConfig{Level: "info"},
+		Storage: StorageConfig{Backend: "postgres", SQLitePath: "./data/product.db"},
+		DB: DBConfig{
+			Host:            "localhost",
+			Port:            5432,
+			User:            "postgres",
+			Password:        "postgres",
+			Name:            "postgres",
+			SSLMode:         "disable",
+			MaxOpenConns:    25,
+			MaxIdleConns:    10,
+			ConnMaxLifetime: 30 * time.Minute,
+			ConnMaxIdleTime: 5 * time.Minute,
+			ConnectTimeout:  time.Minute,
+			QueryTimeout:    10 * time.Second,
+			MigrateOnStart:  true,
+		},
+		Media: MediaConfig{Dir: "./media", BaseURL: "/media", MaxImagePixels: 40_000_000},
+		Auth: AuthConfig{
+			Mode:            "remote",
+			JWKSRefresh:     15 * time.Minute,
+			HTTPTimeout:     3 * time.Second,
+			CacheTTL:        30 * time.Second,
+			CacheSize:       10000,
+			BreakerFailures: 5,
+			BreakerCooldown: 30 * time.Second,
+		},
+		CORS: CORSConfig{
+			AllowedOrigins: []string{"https://kopinofu.com", "https://*.kopinofu.com"},
+			AllowedMethods: []string{"GET", "POST", "PUT", "PATCH", "DELETE"},
+			AllowedHeaders: []string{
+				"Accept", "Accept-Language", "Content-Type", "Content-Length", "Accept-Encoding", "Authorization",
+				"X-CSRF-Token", "X-Tenant-ID", "X-Request-ID", "If-None-Match", "traceparent", "tracestate",
+			},
+			ExposedHeaders: []string{
+				"ETag", "X-Request-ID", "Retry-After", "Content-Language",
+				"RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset",
+			},
+			MaxAge: 10 * time.Minute,
+		},
+		Catalog: CatalogConfig{CacheMaxAge: time.Minute, RateLimit: 5, RateBurst: 20},
+		RateLimit: RateLimitConfig{
+			Enabled:     true,
+			Store:       "memory",
+			RedisPrefix: "nofu_product:ratelimit:",
+			AuthRate:    10,
+			AuthBurst:   30,
+			ReadRate:    10,
+			ReadBurst:   30,
+			WriteRate:   2,
+			WriteBurst:  10,
+		},
+		Tenant:  TenantConfig{DefaultID: "default", DefaultTimezone: "Asia/Jakarta"},
+		Tracing: TracingConfig{Exporter: "none", ServiceName: "nofu-be_product", SampleRatio: 1},
+		Metrics: MetricsConfig{Port: 9090, ProductsCacheTTL: 30 * time.Second, LowStockThreshold: 5},
 	}
-	return fallback
 }
@@ -0,0 +1,266 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-yaml"
+	"github.com/joho/godotenv"
+	"github.com/pelletier/go-toml/v2"
+)
+
+// FileEnv names the environment variable that points to the config file
+const FileEnv = "CONFIG_FILE"
+
+// Load builds the configuration from the defaults, the YAML or TOML file named by
+// CONFIG_FILE and the environment, in increasing order of precedence. Secrets can be read
+// from files instead: DB_PASS_FILE in the environment or db.password_file in the config
+// file. All problems are reported at once.
+func Load() (*Config, error) {
+	// Load .env file if it exists
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using system environment variables")
+	}
+
+	cfg := Default()
+	settings := settingsOf(&cfg)
+
+	var errs []error
+	if path := os.Getenv(FileEnv); path != "" {
+		errs = append(errs, applyFile(path, settings)...)
+	}
+	errs = append(errs, applyEnv(settings)...)
+	// Settings that failed to parse keep their valid defaults, so this adds no duplicates
+	errs = append(errs, cfg.validate()...)
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("invalid configuration:\n  %w", joinLines(errs))
+	}
+	return &cfg, nil
+}
+
+// setting is one configurable field
+type setting struct {
+	key    string // file key, e.g. db.sslmode
+	env    string
+	secret bool
+	value  reflect.Value
+}
+
+// settingsOf lists the settings of cfg in declaration order
+func settingsOf(cfg *Config) []setting {
+	var settings []setting
+	sections := reflect.ValueOf(cfg).Elem()
+	for i := 0; i < sections.NumField(); i++ {
+		section := sections.Field(i)
+		prefix := sections.Type().Field(i).Tag.Get("conf")
+		for j := 0; j < section.NumField(); j++ {
+			f := section.Type().Field(j)
+			settings = append(settings, setting{
+				key:    prefix + "." + f.Tag.Get("conf"),
+				env:    f.Tag.Get("env"),
+				secret: f.Tag.Get("secret") == "true",
+				value:  section.Field(j),
+			})
+		}
+	}
+	return settings
+}
+
+// applyFile sets the settings found in the config file at path
+func applyFile(path string, settings []setting) []error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return []error{fmt.Errorf("%s: %w", FileEnv, err)}
+	}
+
+	doc := map[string]interface{}{}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(b, &doc)
+	case ".toml":
+		err = toml.Unmarshal(b, &doc)
+	default:
+		return []error{fmt.Errorf("%s: unsupported format %q, expected .yaml, .yml or .toml", FileEnv, ext)}
+	}
+	if err != nil {
+		return []error{fmt.Errorf("%s: failed to parse %s: %w", FileEnv, path, err)}
+	}
+
+	values := map[string]string{}
+	flatten("", doc, values)
+
+	var errs []error
+	for _, s := range settings {
+		raw, hasValue := values[s.key]
+		delete(values, s.key)
+		if s.secret {
+			fileKey := s.key + "_file"
+			secretPath, hasFile := values[fileKey]
+			delete(values, fileKey)
+			if hasValue && hasFile {
+				errs = append(errs, fmt.Errorf("%s: set either %s or %s, not both", path, s.key, fileKey))
+				continue
+			}
+			if hasFile {
+				if raw, err = readSecret(secretPath); err != nil {
+					errs = append(errs, fmt.Errorf("%s: %s: %w", path, fileKey, err))
+					continue
+				}
+				hasValue = true
+			}
+		}
+		if !hasValue {
+			continue
+		}
+		if err := set(s.value, raw); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %s: %w", path, s.key, err))
+		}
+	}
+
+	// Unknown keys are most likely typos that would otherwise be silently ignored
+	unknown := make([]string, 0, len(values))
+	for key := range values {
+		unknown = append(unknown, key)
+	}
+	sort.Strings(unknown)
+	for _, key := range unknown {
+		errs = append(errs, fmt.Errorf("%s: unknown setting %s", path, key))
+	}
+	return errs
+}
+
+// applyEnv sets the settings found in the environment
+func applyEnv(settings []setting) []error {
+	var errs []error
+	for _, s := range settings {
+		raw, hasValue := os.LookupEnv(s.env)
+		if s.secret {
+			fileEnv := s.env + "_FILE"
+			secretPath, hasFile := os.LookupEnv(fileEnv)
+			if hasValue && hasFile {
+				errs = append(errs, fmt.Errorf("set either %s or %s, not both", s.env, fileEnv))
+				continue
+			}
+			if hasFile {
+				var err error
+				if raw, err = readSecret(secretPath); err != nil {
+					errs = append(errs, fmt.Errorf("%s: %w", fileEnv, err))
+					continue
+				}
+				hasValue = true
+			}
+		}
+		if !hasValue {
+			continue
+		}
+		if err := set(s.value, raw); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", s.env, err))
+		}
+	}
+	return errs
+}
+
+// readSecret reads a secret from a file such as a mounted Docker or Kubernetes secret,
+// without the trailing newline editors add
+func readSecret(path string) (string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(b), "\r\n"), nil
+}
+
+// flatten turns nested tables into dotted keys with their values as text, the form values
+// have in the environment. Lists become comma-separated.
+func flatten(prefix string, doc map[string]interface{}, values map[string]string) {
+	for k, v := range doc {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		switch v := v.(type) {
+		case map[string]interface{}:
+			flatten(key, v, values)
+		case []interface{}:
+			items := make([]string, len(v))
+			for i, item := range v {
+				items[i] = fmt.Sprint(item)
+			}
+			values[key] = strings.Join(items, ",")
+		case nil:
+			values[key] = ""
+		default:
+			values[key] = fmt.Sprint(v)
+		}
+	}
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// set parses raw into the setting field v
+func set(v reflect.Value, raw string) error {
+	// Strings are kept as they are, a password may well end in a space
+	if v.Kind() == reflect.String {
+		v.SetString(raw)
+		return nil
+	}
+
+	raw = strings.TrimSpace(raw)
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q, expected e.g. 500ms, 30s or 5m", raw)
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Int:
+		i, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", raw)
+		}
+		v.SetInt(int64(i))
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", raw)
+		}
+		v.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q, expected true or false", raw)
+		}
+		v.SetBool(b)
+	case reflect.Slice:
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported setting type %s", v.Type())
+	}
+	return nil
+}
+
+// joinLines joins errs one per line
+func joinLines(errs []error) error {
+	msgs := make([]string, len(errs))
+	for i, err := range errs {
+		msgs[i] = err.Error()
+	}
+	return errors.New(strings.Join(msgs, "\n  "))
+}
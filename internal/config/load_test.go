@@ -0,0 +1,117 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// setupEnv clears every setting from the environment, so the host environment cannot leak
+// into a test, and sets the ones Load requires
+func setupEnv(t *testing.T) {
+	cfg := Default()
+	for _, s := range settingsOf(&cfg) {
+		t.Setenv(s.env, "")
+		os.Unsetenv(s.env)
+		if s.secret {
+			t.Setenv(s.env+"_FILE", "")
+			os.Unsetenv(s.env + "_FILE")
+		}
+	}
+	t.Setenv(FileEnv, "")
+	t.Setenv("AUTH_SERVICE_URL", "http://auth.test/validate")
+}
+
+// writeFile writes content to name in a temporary directory and returns its path
+func writeFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	setupEnv(t)
+	t.Setenv(FileEnv, writeFile(t, "config.yaml", `
+server:
+  port: 8000
+log:
+  level: debug
+db:
+  host: db.file
+`))
+	t.Setenv("APP_PORT", "9000")
+	t.Setenv("DB_HOST", "db.env")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Server.Port != 9000 {
+		t.Errorf("server.port = %d, want 9000 from the environment over the file", cfg.Server.Port)
+	}
+	if cfg.DB.Host != "db.env" {
+		t.Errorf("db.host = %q, want db.env from the environment over the file", cfg.DB.Host)
+	}
+	if cfg.Log.Level != "debug" {
+		t.Errorf("log.level = %q, want debug from the file over the default", cfg.Log.Level)
+	}
+	if cfg.DB.Port != 5432 {
+		t.Errorf("db.port = %d, want the default 5432", cfg.DB.Port)
+	}
+}
+
+func TestLoadSecretFile(t *testing.T) {
+	t.Run("from DB_PASS_FILE", func(t *testing.T) {
+		setupEnv(t)
+		t.Setenv("DB_PASS_FILE", writeFile(t, "db_password", "s3cret \n"))
+
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		if cfg.DB.Password != "s3cret " {
+			t.Errorf("password = %q, want the file without its trailing newline", cfg.DB.Password)
+		}
+	})
+
+	t.Run("DB_PASS with DB_PASS_FILE", func(t *testing.T) {
+		setupEnv(t)
+		t.Setenv("DB_PASS", "inline")
+		t.Setenv("DB_PASS_FILE", writeFile(t, "db_password", "s3cret"))
+
+		_, err := Load()
+		if err == nil || !strings.Contains(err.Error(), "set either DB_PASS or DB_PASS_FILE, not both") {
+			t.Errorf("Load() error = %v, want DB_PASS and DB_PASS_FILE rejected together", err)
+		}
+	})
+
+	t.Run("password with password_file", func(t *testing.T) {
+		setupEnv(t)
+		t.Setenv(FileEnv, writeFile(t, "config.yaml", `
+db:
+  password: inline
+  password_file: `+writeFile(t, "db_password", "s3cret")+`
+`))
+
+		_, err := Load()
+		if err == nil || !strings.Contains(err.Error(), "set either db.password or db.password_file, not both") {
+			t.Errorf("Load() error = %v, want db.password and db.password_file rejected together", err)
+		}
+	})
+}
+
+func TestLoadUnknownKey(t *testing.T) {
+	setupEnv(t)
+	t.Setenv(FileEnv, writeFile(t, "config.yaml", `
+server:
+  prot: 8000
+`))
+
+	_, err := Load()
+	if err == nil || !strings.Contains(err.Error(), "unknown setting server.prot") {
+		t.Errorf("Load() error = %v, want server.prot rejected as unknown", err)
+	}
+}
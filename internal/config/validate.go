@@ -0,0 +1,196 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// checker collects the problems of a configuration, naming each setting by its environment
+// variable and file key
+type checker struct {
+	names map[uintptr]string
+	errs  []error
+}
+
+// check records a problem with the setting field points to unless ok
+func (c *checker) check(ok bool, field interface{}, format string, args ...interface{}) {
+	if ok {
+		return
+	}
+	name := c.names[reflect.ValueOf(field).Pointer()]
+	c.errs = append(c.errs, fmt.Errorf("%s %s", name, fmt.Sprintf(format, args...)))
+}
+
+func (c *checker) oneOf(field *string, allowed ...string) {
+	c.check(slices.Contains(allowed, *field), field, "must be one of %s, got %q", strings.Join(allowed, ", "), *field)
+}
+
+func (c *checker) positive(field *time.Duration) {
+	c.check(*field > 0, field, "must be positive, got %s", *field)
+}
+
+func (c *checker) httpURL(field *string) {
+	u, err := url.Parse(*field)
+	c.check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "", field, "must be an http or https URL, got %q", *field)
+}
+
+// readable checks that the file named by field can be read, if it is set
+func (c *checker) readable(field *string) {
+	if *field == "" {
+		return
+	}
+	f, err := os.Open(*field)
+	if err == nil {
+		f.Close()
+	}
+	c.check(err == nil, field, "must name a readable file: %v", err)
+}
+
+func (c *checker) required(field *string) {
+	c.check(*field != "", field, "is required")
+}
+
+// validProxy reports whether proxy is an IP address or a CIDR range
+func validProxy(proxy string) bool {
+	if strings.Contains(proxy, "/") {
+		_, _, err := net.ParseCIDR(proxy)
+		return err == nil
+	}
+	return net.ParseIP(proxy) != nil
+}
+
+// validOrigin reports whether origin is *, a scheme and host with an optional port, or such
+// an origin whose host starts with "*." to match any subdomain
+func validOrigin(origin string) bool {
+	if origin == "*" {
+		return true
+	}
+	origin = strings.Replace(origin, "://*.", "://wildcard.", 1)
+	u, err := url.Parse(origin)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" &&
+		(u.Path == "" || u.Path == "/") && u.RawQuery == "" && u.User == nil && !strings.Contains(origin, "*")
+}
+
+// validate reports every invalid setting
+func (cfg *Config) validate() []error {
+	c := &checker{names: map[uintptr]string{}}
+	for _, s := range settingsOf(cfg) {
+		c.names[s.value.Addr().Pointer()] = fmt.Sprintf("%s (%s)", s.env, s.key)
+	}
+
+	srv := &cfg.Server
+	c.check(srv.Port > 0 && srv.Port < 65536, &srv.Port, "must be a port between 1 and 65535, got %d", srv.Port)
+	c.positive(&srv.ReadHeaderTimeout)
+	c.positive(&srv.ReadTimeout)
+	c.positive(&srv.WriteTimeout)
+	c.positive(&srv.IdleTimeout)
+	c.positive(&srv.ShutdownTimeout)
+	c.check(srv.ShutdownDelay >= 0, &srv.ShutdownDelay, "must not be negative, got %s", srv.ShutdownDelay)
+	c.positive(&srv.ReadyTimeout)
+	for _, proxy := range srv.TrustedProxies {
+		c.check(validProxy(proxy), &srv.TrustedProxies, "must list IPs or CIDRs such as 10.0.0.0/8, got %q", proxy)
+	}
+
+	_, err := zapcore.ParseLevel(cfg.Log.Level)
+	c.check(err == nil, &cfg.Log.Level, "must be one of debug, info, warn or error, got %q", cfg.Log.Level)
+
+	c.oneOf(&cfg.Storage.Backend, "postgres", "sqlite", "memory")
+	switch cfg.Storage.Backend {
+	case "postgres":
+		db := &cfg.DB
+		c.required(&db.Host)
+		c.check(db.Port > 0 && db.Port < 65536, &db.Port, "must be a port between 1 and 65535, got %d", db.Port)
+		c.required(&db.User)
+		c.required(&db.Name)
+		c.oneOf(&db.SSLMode, "disable", "require", "verify-ca", "verify-full")
+		c.readable(&db.SSLRootCert)
+		c.readable(&db.SSLCert)
+		c.readable(&db.SSLKey)
+		c.check((db.SSLCert == "") == (db.SSLKey == ""), &db.SSLKey, "and DB_SSLCERT must be set together")
+		c.check(db.SSLMode != "disable" || db.SSLRootCert+db.SSLCert == "", &db.SSLMode, "must not be disable when certificates are configured")
+		c.check(db.MaxOpenConns >= 0, &db.MaxOpenConns, "must not be negative, got %d", db.MaxOpenConns)
+		c.check(db.MaxIdleConns >= 0, &db.MaxIdleConns, "must not be negative, got %d", db.MaxIdleConns)
+		c.check(db.MaxOpenConns == 0 || db.MaxIdleConns <= db.MaxOpenConns, &db.MaxIdleConns,
+			"must not exceed DB_MAX_OPEN_CONNS (%d), got %d", db.MaxOpenConns, db.MaxIdleConns)
+		c.check(db.ConnMaxLifetime >= 0, &db.ConnMaxLifetime, "must not be negative, got %s", db.ConnMaxLifetime)
+		c.check(db.ConnMaxIdleTime >= 0, &db.ConnMaxIdleTime, "must not be negative, got %s", db.ConnMaxIdleTime)
+		c.positive(&db.ConnectTimeout)
+	case "sqlite":
+		c.required(&cfg.Storage.SQLitePath)
+	}
+	c.check(cfg.DB.QueryTimeout >= 0, &cfg.DB.QueryTimeout, "must not be negative, got %s", cfg.DB.QueryTimeout)
+
+	c.required(&cfg.Media.Dir)
+	c.required(&cfg.Media.BaseURL)
+	c.check(cfg.Media.MaxImagePixels > 0, &cfg.Media.MaxImagePixels, "must be positive, got %d", cfg.Media.MaxImagePixels)
+
+	auth := &cfg.Auth
+	c.oneOf(&auth.Mode, "remote", "jwt", "jwt+remote")
+	if auth.Mode == "remote" || auth.Mode == "jwt+remote" {
+		c.httpURL(&auth.ServiceURL)
+	}
+	if auth.Mode == "jwt" || auth.Mode == "jwt+remote" {
+		c.check(auth.JWKSURL != "" || auth.JWKSFile != "", &auth.JWKSURL, "or AUTH_JWKS_FILE is required for AUTH_MODE %s", auth.Mode)
+		if auth.JWKSURL != "" {
+			c.httpURL(&auth.JWKSURL)
+		}
+		c.positive(&auth.JWKSRefresh)
+	}
+	c.positive(&auth.HTTPTimeout)
+	c.check(auth.CacheTTL >= 0, &auth.CacheTTL, "must not be negative, got %s", auth.CacheTTL)
+	c.check(auth.CacheTTL == 0 || auth.CacheSize > 0, &auth.CacheSize, "must be positive while caching is enabled, got %d", auth.CacheSize)
+	c.check(auth.BreakerFailures >= 0, &auth.BreakerFailures, "must not be negative, got %d", auth.BreakerFailures)
+	c.positive(&auth.BreakerCooldown)
+
+	cors := &cfg.CORS
+	for _, origin := range cors.AllowedOrigins {
+		c.check(validOrigin(origin), &cors.AllowedOrigins,
+			"must list origins such as https://kopinofu.com, patterns such as https://*.kopinofu.com or *, got %q", origin)
+	}
+	c.check(!cors.AllowCredentials || !slices.Contains(cors.AllowedOrigins, "*"), &cors.AllowCredentials,
+		"must not be set while CORS_ALLOWED_ORIGINS allows any origin (*)")
+	c.check(len(cors.AllowedMethods) > 0, &cors.AllowedMethods, "is required")
+	c.check(cors.MaxAge >= 0, &cors.MaxAge, "must not be negative, got %s", cors.MaxAge)
+
+	c.check(cfg.Catalog.CacheMaxAge >= 0, &cfg.Catalog.CacheMaxAge, "must not be negative, got %s", cfg.Catalog.CacheMaxAge)
+	c.check(cfg.Catalog.RateLimit > 0, &cfg.Catalog.RateLimit, "must be positive, got %g", cfg.Catalog.RateLimit)
+	c.check(cfg.Catalog.RateBurst > 0, &cfg.Catalog.RateBurst, "must be positive, got %d", cfg.Catalog.RateBurst)
+
+	rl := &cfg.RateLimit
+	c.oneOf(&rl.Store, "memory", "redis")
+	if rl.Store == "redis" {
+		// The URL may hold a password, so it is not repeated
+		u, err := url.Parse(rl.RedisURL)
+		c.check(err == nil && (u.Scheme == "redis" || u.Scheme == "rediss") && u.Host != "", &rl.RedisURL,
+			"must be a redis:// or rediss:// URL with RATE_LIMIT_STORE=redis")
+	}
+	for _, limit := range []struct {
+		rate  *float64
+		burst *int
+	}{{&rl.AuthRate, &rl.AuthBurst}, {&rl.ReadRate, &rl.ReadBurst}, {&rl.WriteRate, &rl.WriteBurst}} {
+		c.check(*limit.rate > 0, limit.rate, "must be positive, got %g", *limit.rate)
+		c.check(*limit.burst > 0, limit.burst, "must be positive, got %d", *limit.burst)
+	}
+
+	_, err = time.LoadLocation(cfg.Tenant.DefaultTimezone)
+	c.check(err == nil && cfg.Tenant.DefaultTimezone != "", &cfg.Tenant.DefaultTimezone, "must be an IANA time zone such as Asia/Jakarta, got %q", cfg.Tenant.DefaultTimezone)
+
+	c.oneOf(&cfg.Tracing.Exporter, "none", "stdout", "otlp")
+	c.required(&cfg.Tracing.ServiceName)
+	c.check(cfg.Tracing.SampleRatio >= 0 && cfg.Tracing.SampleRatio <= 1, &cfg.Tracing.SampleRatio, "must be between 0 and 1, got %g", cfg.Tracing.SampleRatio)
+
+	m := &cfg.Metrics
+	c.check(m.Port >= 0 && m.Port < 65536, &m.Port, "must be a port between 1 and 65535, or 0 to disable, got %d", m.Port)
+	c.check(m.Port == 0 || m.Port != cfg.Server.Port, &m.Port, "must differ from APP_PORT (%d)", cfg.Server.Port)
+	c.check(m.ProductsCacheTTL >= 0, &m.ProductsCacheTTL, "must not be negative, got %s", m.ProductsCacheTTL)
+	c.check(cfg.Metrics.LowStockThreshold >= 0, &cfg.Metrics.LowStockThreshold, "must not be negative, got %d", cfg.Metrics.LowStockThreshold)
+	return c.errs
+}
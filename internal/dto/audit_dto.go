@@ -0,0 +1,20 @@
+package dto
+
+import "time"
+
+// AuditEntryResponse is a product audit log entry returned to clients
+type AuditEntryResponse struct {
+	ID        string                         `json:"id"`
+	ProductID string                         `json:"productId"`
+	Action    string                         `json:"action"`
+	ActorID   string                         `json:"actorId"`
+	ActorName string                         `json:"actorName"`
+	Changes   map[string]FieldChangeResponse `json:"changes,omitempty"`
+	CreatedAt time.Time                      `json:"createdAt"`
+}
+
+// FieldChangeResponse holds the previous and new value of a changed field
+type FieldChangeResponse struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
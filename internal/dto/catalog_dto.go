@@ -0,0 +1,16 @@
+package dto
+
+// CatalogProductResponse is the public, customer-safe view of a product
+type CatalogProductResponse struct {
+	ID          string                          `json:"id"`
+	Name        string                          `json:"name"`
+	Description string                          `json:"description"`
+	Price       float64                         `json:"price"`
+	Currency    string                          `json:"currency"`
+	Url         string                          `json:"url"`
+	Images      map[string]ProductImageResponse `json:"images"`
+	SoldOut     bool                            `json:"soldOut"`
+	OutletID    string                          `json:"outletId,omitempty"`
+	// Availability lists the ordering windows; empty means always orderable
+	Availability []AvailabilityRuleResponse `json:"availability"`
+}
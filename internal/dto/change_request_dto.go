@@ -0,0 +1,40 @@
+package dto
+
+import "time"
+
+// ChangeRequestQuery holds the query parameters accepted by the change request listing
+type ChangeRequestQuery struct {
+	Status    string `form:"status" binding:"omitempty,oneof=pending approved rejected"`
+	ProductID string `form:"product"`
+}
+
+// ReviewChangeRequest is a reviewer's decision note on a change request
+type ReviewChangeRequest struct {
+	Comment string `json:"comment" binding:"max=1000"`
+}
+
+// ChangeRequestResponse is a pending or reviewed edit to a published product
+type ChangeRequestResponse struct {
+	ID        string `json:"id"`
+	ProductID string `json:"productId"`
+	Status    string `json:"status"`
+	// Changes previews the edit against the product as it was when requested
+	Changes         map[string]FieldChangeResponse `json:"changes"`
+	RequestedBy     string                         `json:"requestedBy"`
+	RequestedByName string                         `json:"requestedByName"`
+	ReviewedBy      string                         `json:"reviewedBy,omitempty"`
+	ReviewComment   string                         `json:"reviewComment,omitempty"`
+	CreatedAt       time.Time                      `json:"createdAt"`
+	ReviewedAt      *time.Time                     `json:"reviewedAt,omitempty"`
+}
+
+// NotificationResponse is a message for the authenticated user
+type NotificationResponse struct {
+	ID              string     `json:"id"`
+	Type            string     `json:"type"`
+	Message         string     `json:"message"`
+	ProductID       string     `json:"productId,omitempty"`
+	ChangeRequestID string     `json:"changeRequestId,omitempty"`
+	CreatedAt       time.Time  `json:"createdAt"`
+	ReadAt          *time.Time `json:"readAt"`
+}
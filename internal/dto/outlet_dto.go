@@ -0,0 +1,51 @@
+package dto
+
+import "time"
+
+// CreateOutletRequest is the outlet data for creation
+type CreateOutletRequest struct {
+	Name     string `json:"name" binding:"required,min=2"`
+	Address  string `json:"address"`
+	Timezone string `json:"timezone" binding:"omitempty,timezone"`
+	Active   *bool  `json:"active"`
+}
+
+// UpdateOutletRequest is the partial outlet data for updates
+type UpdateOutletRequest struct {
+	Name     *string `json:"name,omitempty" binding:"omitempty,min=2"`
+	Address  *string `json:"address,omitempty"`
+	Timezone *string `json:"timezone,omitempty" binding:"omitempty,timezone"`
+	Active   *bool   `json:"active,omitempty"`
+}
+
+// OutletResponse is the outlet data returned to clients
+type OutletResponse struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Address   string    `json:"address"`
+	Timezone  string    `json:"timezone"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// UpdateProductOutletRequest replaces the settings of a product at one outlet.
+// Omitted priceOverride and stock fall back to the product's global values.
+type UpdateProductOutletRequest struct {
+	PriceOverride *float64 `json:"priceOverride" binding:"omitempty,gt=0"`
+	Stock         *int64   `json:"stock" binding:"omitempty,min=0"`
+	Available     *bool    `json:"available"`
+	SoldOut       bool     `json:"soldOut"`
+}
+
+// ProductOutletResponse is the settings of a product at one outlet
+type ProductOutletResponse struct {
+	ProductID     string    `json:"productId"`
+	OutletID      string    `json:"outletId"`
+	PriceOverride *float64  `json:"priceOverride"`
+	Stock         *int64    `json:"stock"`
+	Available     bool      `json:"available"`
+	SoldOut       bool      `json:"soldOut"`
+	UpdatedBy     string    `json:"updatedBy"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+}
@@ -10,7 +10,8 @@ type CreateProductRequest struct {
 	Currency    string  `json:"currency" binding:"required"`
 	Url         string  `json:"url" binding:"required"`
 	Stock       *int64  `json:"stock" binding:"required,min=0"`
-	Active      *bool   `json:"active"`
+	// Availability restricts ordering to the listed windows. Omitted or empty means always.
+	Availability []AvailabilityRuleRequest `json:"availability" binding:"omitempty,dive"`
 }
 
 // UpdateProductRequest is the partial product data for updates
@@ -18,8 +19,35 @@ type UpdateProductRequest struct {
 	Name        *string  `json:"name,omitempty" binding:"omitempty,min=3"`
 	Description *string  `json:"description,omitempty"`
 	Price       *float64 `json:"price,omitempty" binding:"omitempty,gt=0"`
-	Stock       *int64   `json:"stock,omitempty" binding:"omitempty,min=0"`
-	Active      *bool    `json:"active,omitempty"`
+	// Availability replaces all rules when present; an empty list removes them
+	Availability *[]AvailabilityRuleRequest `json:"availability,omitempty" binding:"omitempty,dive"`
+}
+
+// AvailabilityRuleRequest is a window in which a product can be ordered, in the outlet's local time.
+// Weekdays are 0 (Sunday) to 6; omitted fields do not restrict. An endTime at or before startTime spans
+// midnight, so equal times allow the whole day. endDate may not be before startDate.
+type AvailabilityRuleRequest struct {
+	Weekdays  []int  `json:"weekdays" binding:"omitempty,dive,min=0,max=6"`
+	StartTime string `json:"startTime" binding:"required_with=EndTime,omitempty,datetime=15:04"`
+	EndTime   string `json:"endTime" binding:"required_with=StartTime,omitempty,datetime=15:04"`
+	StartDate string `json:"startDate" binding:"omitempty,datetime=2006-01-02"`
+	EndDate   string `json:"endDate" binding:"omitempty,datetime=2006-01-02"`
+}
+
+// ProductQuery holds the query parameters accepted by the product listing and detail endpoints
+type ProductQuery struct {
+	// OutletID resolves the effective price, stock and availability at that outlet
+	OutletID string `form:"outlet"`
+	// Status keeps only products in that lifecycle status (ignored by the public catalog)
+	Status string `form:"status" binding:"omitempty,oneof=draft in_review published archived"`
+	// AvailableAt keeps only products that can be ordered at that moment (RFC 3339)
+	AvailableAt *time.Time `form:"available_at" time_format:"2006-01-02T15:04:05Z07:00"`
+}
+
+// AdjustStockRequest adds (or, when negative, removes) units from the current stock
+type AdjustStockRequest struct {
+	Delta  int64  `json:"delta" binding:"required,ne=0"`
+	Reason string `json:"reason" binding:"max=255"`
 }
 
 // ProductResponse is the full product data returned to clients
@@ -31,8 +59,39 @@ type ProductResponse struct {
 	CreatedAt   time.Time `json:"createdAt"`
 	UpdatedAt   time.Time `json:"updatedAt"`
 	CreatedBy   string    `json:"createdBy"`
+	UpdatedBy   string    `json:"updatedBy"`
 	Url         string    `json:"url"`
 	Currency    string    `json:"currency"`
 	Stock       int64     `json:"stock"`
-	Active      bool      `json:"active"`
+	// Status is the lifecycle status: draft, in_review, published or archived
+	Status      string     `json:"status"`
+	SubmittedAt *time.Time `json:"submittedAt"`
+	PublishedAt *time.Time `json:"publishedAt"`
+	ArchivedAt  *time.Time `json:"archivedAt"`
+	// Available is set for published products that are not disabled at the outlet
+	Available bool `json:"available"`
+	SoldOut   bool `json:"soldOut"`
+	// OutletID is set when price, stock and availability were resolved for an outlet
+	OutletID string `json:"outletId,omitempty"`
+	// Images holds the generated derivatives keyed by size (thumbnail, card, detail)
+	Images map[string]ProductImageResponse `json:"images"`
+	// Availability lists the ordering windows; empty means always orderable
+	Availability []AvailabilityRuleResponse `json:"availability"`
+}
+
+// AvailabilityRuleResponse is a window in which a product can be ordered
+type AvailabilityRuleResponse struct {
+	Weekdays  []int  `json:"weekdays,omitempty"`
+	StartTime string `json:"startTime,omitempty"`
+	EndTime   string `json:"endTime,omitempty"`
+	StartDate string `json:"startDate,omitempty"`
+	EndDate   string `json:"endDate,omitempty"`
+}
+
+// ProductImageResponse is a resized rendition of the product photo
+type ProductImageResponse struct {
+	Url     string `json:"url"`
+	WebpUrl string `json:"webpUrl"`
+	Width   int    `json:"width"`
+	Height  int    `json:"height"`
 }
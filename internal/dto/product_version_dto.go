@@ -0,0 +1,25 @@
+package dto
+
+import "time"
+
+// ProductVersionDiffQuery selects the two revisions to compare
+type ProductVersionDiffQuery struct {
+	From int `form:"from" binding:"required,min=1"`
+	To   int `form:"to" binding:"required,min=1"`
+}
+
+// ProductVersionResponse is a revision of a product with its full snapshot
+type ProductVersionResponse struct {
+	Version   int              `json:"version"`
+	Action    string           `json:"action"`
+	ActorID   string           `json:"actorId"`
+	CreatedAt time.Time        `json:"createdAt"`
+	Product   *ProductResponse `json:"product"`
+}
+
+// ProductVersionDiffResponse lists the fields that differ between two revisions
+type ProductVersionDiffResponse struct {
+	From    int                            `json:"from"`
+	To      int                            `json:"to"`
+	Changes map[string]FieldChangeResponse `json:"changes"`
+}
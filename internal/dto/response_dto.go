@@ -0,0 +1,61 @@
+package dto
+
+import "encoding/json"
+
+// Response is the envelope of every API response. Failed requests additionally carry an
+// RFC 7807 problem, whose members sit next to the envelope fields.
+type Response struct {
+	ResponseCode    string      `json:"responseCode"`
+	ResponseMessage string      `json:"responseMessage"`
+	Data            interface{} `json:"data"`
+	*Problem
+}
+
+// Problem describes why a request failed
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance"`
+	// Code identifies the problem; unlike the title it is never translated
+	Code string `json:"code"`
+	// Errors lists each invalid field of a request that failed validation
+	Errors []FieldError `json:"errors,omitempty"`
+	// Extensions are additional problem members, e.g. missingPermission
+	Extensions map[string]interface{} `json:"-"`
+}
+
+// FieldError is a request field that broke a validation rule
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+// MarshalJSON adds the problem extensions as top-level members; they never replace the
+// standard ones
+func (r Response) MarshalJSON() ([]byte, error) {
+	type response Response
+	b, err := json.Marshal(response(r))
+	if err != nil || r.Problem == nil || len(r.Problem.Extensions) == 0 {
+		return b, err
+	}
+
+	members := map[string]json.RawMessage{}
+	if err := json.Unmarshal(b, &members); err != nil {
+		return nil, err
+	}
+	for k, v := range r.Problem.Extensions {
+		if _, ok := members[k]; ok {
+			continue
+		}
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		members[k] = raw
+	}
+	return json.Marshal(members)
+}
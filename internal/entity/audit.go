@@ -0,0 +1,37 @@
+package entity
+
+import "time"
+
+// Audit actions recorded for products
+const (
+	AuditActionCreate      = "create"
+	AuditActionUpdate      = "update"
+	AuditActionDelete      = "delete"
+	AuditActionUploadImage = "upload_image"
+	AuditActionAdjustStock = "adjust_stock"
+	AuditActionOutlet      = "update_outlet_settings"
+	AuditActionStatus      = "change_status"
+	AuditActionRollback    = "rollback"
+	// AuditActionImages marks the revision storing the derivatives generated for a photo
+	AuditActionImages = "generate_images"
+	// AuditActionBaseline marks the first revision of products created before revisions were kept
+	AuditActionBaseline = "baseline"
+)
+
+// AuditEntry records who changed a product and how
+type AuditEntry struct {
+	ID        string                 `json:"id"`
+	TenantID  string                 `json:"tenant_id"`
+	ProductID string                 `json:"product_id"`
+	Action    string                 `json:"action"`
+	ActorID   string                 `json:"actor_id"`
+	ActorName string                 `json:"actor_name"`
+	Changes   map[string]FieldChange `json:"changes"`
+	CreatedAt time.Time              `json:"created_at"`
+}
+
+// FieldChange holds the previous and new value of a changed field
+type FieldChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
@@ -0,0 +1,37 @@
+package entity
+
+import "time"
+
+// ChangeRequestStatus is the review state of a change request
+type ChangeRequestStatus string
+
+const (
+	ChangeRequestPending  ChangeRequestStatus = "pending"
+	ChangeRequestApproved ChangeRequestStatus = "approved"
+	ChangeRequestRejected ChangeRequestStatus = "rejected"
+)
+
+// ProductPatch is a partial product update. Nil fields are left unchanged.
+type ProductPatch struct {
+	Name         *string             `json:"name,omitempty"`
+	Description  *string             `json:"description,omitempty"`
+	Price        *float64            `json:"price,omitempty"`
+	Availability *[]AvailabilityRule `json:"availability,omitempty"`
+}
+
+// ChangeRequest is an edit to a published product awaiting review
+type ChangeRequest struct {
+	ID        string              `json:"id"`
+	TenantID  string              `json:"tenant_id"`
+	ProductID string              `json:"product_id"`
+	Status    ChangeRequestStatus `json:"status"`
+	Patch     ProductPatch        `json:"patch"`
+	// Changes previews the patch against the product as it was when the change was requested
+	Changes         map[string]FieldChange `json:"changes"`
+	RequestedBy     string                 `json:"requested_by"`
+	RequestedByName string                 `json:"requested_by_name"`
+	ReviewedBy      string                 `json:"reviewed_by"`
+	ReviewComment   string                 `json:"review_comment"`
+	CreatedAt       time.Time              `json:"created_at"`
+	ReviewedAt      *time.Time             `json:"reviewed_at"`
+}
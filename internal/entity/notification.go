@@ -0,0 +1,22 @@
+package entity
+
+import "time"
+
+// Notification types
+const (
+	NotificationChangeApproved = "change_request_approved"
+	NotificationChangeRejected = "change_request_rejected"
+)
+
+// Notification is a message for a single user, e.g. the outcome of their change request
+type Notification struct {
+	ID              string     `json:"id"`
+	TenantID        string     `json:"tenant_id"`
+	UserID          string     `json:"user_id"`
+	Type            string     `json:"type"`
+	Message         string     `json:"message"`
+	ProductID       string     `json:"product_id"`
+	ChangeRequestID string     `json:"change_request_id"`
+	CreatedAt       time.Time  `json:"created_at"`
+	ReadAt          *time.Time `json:"read_at"`
+}
@@ -0,0 +1,30 @@
+package entity
+
+import "time"
+
+// Outlet is a store or café of a tenant where products are sold
+type Outlet struct {
+	ID       string `json:"id"`
+	TenantID string `json:"tenant_id"`
+	Name     string `json:"name"`
+	Address  string `json:"address"`
+	// Timezone is the IANA time zone of the outlet. Empty uses the service default.
+	Timezone  string    `json:"timezone"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ProductOutlet holds the outlet-specific settings of a product. Nil overrides fall back
+// to the product's global values.
+type ProductOutlet struct {
+	ProductID     string    `json:"product_id"`
+	OutletID      string    `json:"outlet_id"`
+	TenantID      string    `json:"tenant_id"`
+	PriceOverride *float64  `json:"price_override"`
+	Stock         *int64    `json:"stock"`
+	Available     bool      `json:"available"`
+	SoldOut       bool      `json:"sold_out"`
+	UpdatedBy     string    `json:"updated_by"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
@@ -4,15 +4,53 @@ import "time"
 
 // Product represents the product entity in the domain
 type Product struct {
-	ID          string    `json:"id"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	Price       float64   `json:"price"`
-	Currency    string    `json:"currency"`
-	Url         string    `json:"url"`
-	Stock       int64     `json:"stock"`
-	Active      int16     `json:"active"`
-	CreatedBy   string    `json:"created_by"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          string                  `json:"id"`
+	TenantID    string                  `json:"tenant_id"`
+	Name        string                  `json:"name"`
+	Description string                  `json:"description"`
+	Price       float64                 `json:"price"`
+	Currency    string                  `json:"currency"`
+	Url         string                  `json:"url"`
+	Images      map[string]ProductImage `json:"images"`
+	// Availability restricts when the product can be ordered. No rules means always.
+	Availability []AvailabilityRule `json:"availability"`
+	Stock        int64              `json:"stock"`
+	Status       ProductStatus      `json:"status"`
+	// SubmittedAt, PublishedAt and ArchivedAt record when the product last entered that status
+	SubmittedAt *time.Time `json:"submitted_at"`
+	PublishedAt *time.Time `json:"published_at"`
+	ArchivedAt  *time.Time `json:"archived_at"`
+	CreatedBy   string     `json:"created_by"`
+	UpdatedBy   string     `json:"updated_by"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// ProductStatus is the lifecycle state of a product. Only published products are sold.
+type ProductStatus string
+
+const (
+	ProductStatusDraft     ProductStatus = "draft"
+	ProductStatusInReview  ProductStatus = "in_review"
+	ProductStatusPublished ProductStatus = "published"
+	ProductStatusArchived  ProductStatus = "archived"
+)
+
+// ProductImage is a resized rendition of the product photo
+type ProductImage struct {
+	Url     string `json:"url"`
+	WebpUrl string `json:"webp_url"`
+	Width   int    `json:"width"`
+	Height  int    `json:"height"`
+}
+
+// AvailabilityRule is a window in which a product can be ordered, evaluated in the local
+// time of the outlet. Empty fields do not restrict: no weekdays means every day, no times
+// mean all day and no dates mean indefinitely. An end time before the start time spans midnight.
+type AvailabilityRule struct {
+	Weekdays  []time.Weekday `json:"weekdays"`
+	StartTime string         `json:"start_time"` // "15:04"
+	EndTime   string         `json:"end_time"`   // "15:04", exclusive
+	StartDate string         `json:"start_date"` // "2006-01-02"
+	EndDate   string         `json:"end_date"`   // "2006-01-02", inclusive
 }
@@ -0,0 +1,15 @@
+package entity
+
+import "time"
+
+// ProductVersion is a full snapshot of a product after one of its revisions
+type ProductVersion struct {
+	TenantID  string `json:"tenant_id"`
+	ProductID string `json:"product_id"`
+	// Version numbers start at 1 and increase by one per revision of the product
+	Version   int       `json:"version"`
+	Action    string    `json:"action"`
+	ActorID   string    `json:"actor_id"`
+	Snapshot  Product   `json:"snapshot"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dominikuswilly/nofu-be_product/internal/dto"
+	"github.com/dominikuswilly/nofu-be_product/internal/middleware"
+	"github.com/dominikuswilly/nofu-be_product/internal/tenant"
+	"github.com/dominikuswilly/nofu-be_product/internal/usecase"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// CatalogHandler serves the public, read-only storefront catalog
+type CatalogHandler struct {
+	usecase       usecase.ProductUsecase
+	logger        *zap.Logger
+	maxAge        time.Duration
+	limiter       *middleware.RateLimiter
+	defaultTenant string
+}
+
+func NewCatalogHandler(usecase usecase.ProductUsecase, logger *zap.Logger, maxAge time.Duration, limiter *middleware.RateLimiter, defaultTenant string) *CatalogHandler {
+	return &CatalogHandler{
+		usecase:       usecase,
+		logger:        logger,
+		maxAge:        maxAge,
+		limiter:       limiter,
+		defaultTenant: defaultTenant,
+	}
+}
+
+func (h *CatalogHandler) RegisterRoutes(r *gin.RouterGroup) {
+	catalog := r.Group("/catalog")
+	catalog.Use(h.limiter.PerClient(middleware.RateGroupCatalog), middleware.TenantMiddleware(h.defaultTenant, nil))
+	{
+		catalog.GET("", h.GetCatalog)
+		catalog.GET("/:id", h.GetCatalogProduct)
+	}
+}
+
+func (h *CatalogHandler) GetCatalog(c *gin.Context) {
+	var query dto.ProductQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		invalidRequest(c, err)
+		return
+	}
+
+	res, err := h.usecase.GetCatalog(c.Request.Context(), query)
+	if err != nil {
+		middleware.Abort(c, err)
+		return
+	}
+
+	h.writeCached(c, dto.Response{ResponseCode: "200", ResponseMessage: "success", Data: res})
+}
+
+func (h *CatalogHandler) GetCatalogProduct(c *gin.Context) {
+	id := c.Param("id")
+
+	var query dto.ProductQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		invalidRequest(c, err)
+		return
+	}
+
+	res, err := h.usecase.GetCatalogProduct(c.Request.Context(), id, query)
+	if err != nil {
+		middleware.Abort(c, err)
+		return
+	}
+
+	h.writeCached(c, dto.Response{ResponseCode: "200", ResponseMessage: "success", Data: res})
+}
+
+// writeCached writes body as a publicly cacheable response with a content-derived ETag,
+// answering 304 Not Modified when the client already has the current version
+func (h *CatalogHandler) writeCached(c *gin.Context, body interface{}) {
+	b, err := json.Marshal(body)
+	if err != nil {
+		middleware.Abort(c, err)
+		return
+	}
+
+	sum := sha256.Sum256(b)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	maxAge := int(h.maxAge.Seconds())
+
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d, s-maxage=%d, stale-while-revalidate=%d", maxAge, maxAge, maxAge*5))
+	// Add rather than set, CORS may already vary the response by Origin
+	c.Writer.Header().Add("Vary", "Accept-Encoding, "+tenant.Header)
+
+	if etagMatches(c.Request.Header.Values("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", b)
+}
+
+// etagMatches reports whether the If-None-Match header values list etag or are *. Tags are
+// compared weakly, ignoring W/, as RFC 9110 section 13.1.2 requires for If-None-Match.
+func etagMatches(ifNoneMatch []string, etag string) bool {
+	for _, value := range ifNoneMatch {
+		for _, tag := range strings.Split(value, ",") {
+			tag = strings.TrimSpace(tag)
+			if tag == "*" || strings.TrimPrefix(tag, "W/") == etag {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,30 @@
+package handler
+
+import "testing"
+
+func TestETagMatches(t *testing.T) {
+	const etag = `"abc"`
+	tests := []struct {
+		name        string
+		ifNoneMatch []string
+		want        bool
+	}{
+		{"no header", nil, false},
+		{"same tag", []string{`"abc"`}, true},
+		{"other tag", []string{`"xyz"`}, false},
+		{"weak tag", []string{`W/"abc"`}, true},
+		{"any tag", []string{`*`}, true},
+		{"one of several tags", []string{`"xyz", W/"abc"`}, true},
+		{"several tags without spaces", []string{`"xyz","abc"`}, true},
+		{"none of several tags", []string{`"xyz", "uvw"`}, false},
+		{"several header lines", []string{`"xyz"`, `"abc"`}, true},
+		{"unquoted tag", []string{`abc`}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := etagMatches(tt.ifNoneMatch, etag); got != tt.want {
+				t.Errorf("etagMatches(%q) = %v, want %v", tt.ifNoneMatch, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/dominikuswilly/nofu-be_product/internal/auth"
+	"github.com/dominikuswilly/nofu-be_product/internal/dto"
+	"github.com/dominikuswilly/nofu-be_product/internal/middleware"
+	"github.com/dominikuswilly/nofu-be_product/internal/usecase"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type ChangeRequestHandler struct {
+	usecase       usecase.ChangeRequestUsecase
+	logger        *zap.Logger
+	validator     auth.Validator
+	policy        *auth.Policy
+	limiter       *middleware.RateLimiter
+	defaultTenant string
+}
+
+func NewChangeRequestHandler(usecase usecase.ChangeRequestUsecase, logger *zap.Logger, validator auth.Validator, policy *auth.Policy, limiter *middleware.RateLimiter, defaultTenant string) *ChangeRequestHandler {
+	return &ChangeRequestHandler{
+		usecase:       usecase,
+		logger:        logger,
+		validator:     validator,
+		policy:        policy,
+		limiter:       limiter,
+		defaultTenant: defaultTenant,
+	}
+}
+
+func (h *ChangeRequestHandler) RegisterRoutes(r *gin.RouterGroup) {
+	changeRequests := r.Group("/change-requests")
+	changeRequests.Use(h.limiter.PerIP(middleware.RateGroupAuth), middleware.AuthMiddleware(h.validator), middleware.TenantMiddleware(h.defaultTenant, h.policy), h.limiter.PerUser())
+	{
+		changeRequests.GET("", h.require(auth.PermProductRead), h.GetChangeRequests)
+		changeRequests.GET("/:id", h.require(auth.PermProductRead), h.GetChangeRequestByID)
+		changeRequests.POST("/:id/approve", h.require(auth.PermProductPublish), h.ApproveChangeRequest)
+		changeRequests.POST("/:id/reject", h.require(auth.PermProductPublish), h.RejectChangeRequest)
+	}
+
+	// Notifications are personal, so any authenticated user may read their own
+	notifications := r.Group("/notifications")
+	notifications.Use(h.limiter.PerIP(middleware.RateGroupAuth), middleware.AuthMiddleware(h.validator), middleware.TenantMiddleware(h.defaultTenant, h.policy), h.limiter.PerUser())
+	{
+		notifications.GET("", h.GetNotifications)
+		notifications.POST("/:id/read", h.MarkNotificationRead)
+	}
+}
+
+func (h *ChangeRequestHandler) require(perm auth.Permission) gin.HandlerFunc {
+	return middleware.RequirePermission(h.policy, perm)
+}
+
+func (h *ChangeRequestHandler) GetChangeRequests(c *gin.Context) {
+	var query dto.ChangeRequestQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		invalidRequest(c, err)
+		return
+	}
+
+	res, err := h.usecase.GetChangeRequests(c.Request.Context(), query)
+	if err != nil {
+		middleware.Abort(c, err)
+		return
+	}
+
+	respond(c, http.StatusOK, "success", res)
+}
+
+func (h *ChangeRequestHandler) GetChangeRequestByID(c *gin.Context) {
+	id := c.Param("id")
+
+	res, err := h.usecase.GetChangeRequestByID(c.Request.Context(), id)
+	if err != nil {
+		middleware.Abort(c, err)
+		return
+	}
+
+	respond(c, http.StatusOK, "success", res)
+}
+
+func (h *ChangeRequestHandler) ApproveChangeRequest(c *gin.Context) {
+	h.review(c, h.usecase.ApproveChangeRequest)
+}
+
+func (h *ChangeRequestHandler) RejectChangeRequest(c *gin.Context) {
+	h.review(c, h.usecase.RejectChangeRequest)
+}
+
+func (h *ChangeRequestHandler) review(c *gin.Context, decide func(ctx context.Context, id string, req dto.ReviewChangeRequest) (*dto.ChangeRequestResponse, error)) {
+	id := c.Param("id")
+
+	var req dto.ReviewChangeRequest
+	// The comment is optional, so an empty body is accepted
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			invalidRequest(c, err)
+			return
+		}
+	}
+
+	res, err := decide(c.Request.Context(), id, req)
+	if err != nil {
+		middleware.Abort(c, err)
+		return
+	}
+
+	respond(c, http.StatusOK, "success", res)
+}
+
+func (h *ChangeRequestHandler) GetNotifications(c *gin.Context) {
+	res, err := h.usecase.GetNotifications(c.Request.Context())
+	if err != nil {
+		middleware.Abort(c, err)
+		return
+	}
+
+	respond(c, http.StatusOK, "success", res)
+}
+
+func (h *ChangeRequestHandler) MarkNotificationRead(c *gin.Context) {
+	id := c.Param("id")
+
+	if err := h.usecase.MarkNotificationRead(c.Request.Context(), id); err != nil {
+		middleware.Abort(c, err)
+		return
+	}
+
+	respond(c, http.StatusOK, "success", nil)
+}
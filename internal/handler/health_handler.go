@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/dominikuswilly/nofu-be_product/internal/apperror"
+	"github.com/dominikuswilly/nofu-be_product/internal/health"
+	"github.com/dominikuswilly/nofu-be_product/internal/logging"
+	"github.com/dominikuswilly/nofu-be_product/internal/middleware"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// ErrNotReady is reported by the readiness probe while a dependency is down or the service
+// is shutting down
+var ErrNotReady = apperror.Unavailable("not_ready", "Service not ready")
+
+// HealthHandler serves the liveness and readiness probes
+type HealthHandler struct {
+	checker *health.Checker
+}
+
+func NewHealthHandler(checker *health.Checker) *HealthHandler {
+	return &HealthHandler{checker: checker}
+}
+
+func (h *HealthHandler) RegisterRoutes(r *gin.RouterGroup) {
+	r.GET("/livez", h.Live)
+	r.GET("/readyz", h.Ready)
+}
+
+// Live reports that the process is running; it checks no dependencies so that an outage
+// of one does not get the service restarted
+func (h *HealthHandler) Live(c *gin.Context) {
+	respond(c, http.StatusOK, "Alive", gin.H{"status": "ok"})
+}
+
+// Ready reports whether the service can serve traffic, with the status and latency of
+// every dependency. Why a dependency is down is logged rather than returned.
+func (h *HealthHandler) Ready(c *gin.Context) {
+	report := h.checker.Ready(c.Request.Context())
+	if report.Ready {
+		respond(c, http.StatusOK, "Ready", report)
+		return
+	}
+
+	logger := logging.FromContext(c.Request.Context())
+	for name, result := range report.Checks {
+		if result.Err != nil {
+			logger.Warn("Dependency is down", zap.String("dependency", name), zap.Error(result.Err))
+		}
+	}
+
+	err := ErrNotReady.WithDetail("a dependency is down")
+	if report.ShuttingDown {
+		err = ErrNotReady.WithDetail("the service is shutting down")
+	}
+	middleware.Abort(c, err.WithExtension("checks", report.Checks))
+}
@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/dominikuswilly/nofu-be_product/internal/auth"
+	"github.com/dominikuswilly/nofu-be_product/internal/dto"
+	"github.com/dominikuswilly/nofu-be_product/internal/middleware"
+	"github.com/dominikuswilly/nofu-be_product/internal/usecase"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type OutletHandler struct {
+	usecase       usecase.OutletUsecase
+	logger        *zap.Logger
+	validator     auth.Validator
+	policy        *auth.Policy
+	limiter       *middleware.RateLimiter
+	defaultTenant string
+}
+
+func NewOutletHandler(usecase usecase.OutletUsecase, logger *zap.Logger, validator auth.Validator, policy *auth.Policy, limiter *middleware.RateLimiter, defaultTenant string) *OutletHandler {
+	return &OutletHandler{
+		usecase:       usecase,
+		logger:        logger,
+		validator:     validator,
+		policy:        policy,
+		limiter:       limiter,
+		defaultTenant: defaultTenant,
+	}
+}
+
+func (h *OutletHandler) RegisterRoutes(r *gin.RouterGroup) {
+	outlets := r.Group("/outlets")
+	outlets.Use(h.limiter.PerIP(middleware.RateGroupAuth), middleware.AuthMiddleware(h.validator), middleware.TenantMiddleware(h.defaultTenant, h.policy), h.limiter.PerUser())
+	{
+		outlets.POST("", h.require(auth.PermOutletWrite), h.CreateOutlet)
+		outlets.GET("", h.require(auth.PermProductRead), h.GetAllOutlets)
+		outlets.GET("/:id", h.require(auth.PermProductRead), h.GetOutletByID)
+		outlets.PUT("/:id", h.require(auth.PermOutletWrite), h.UpdateOutlet)
+		outlets.DELETE("/:id", h.require(auth.PermOutletWrite), h.DeleteOutlet)
+	}
+
+	productOutlets := r.Group("/products/:id/outlets")
+	productOutlets.Use(h.limiter.PerIP(middleware.RateGroupAuth), middleware.AuthMiddleware(h.validator), middleware.TenantMiddleware(h.defaultTenant, h.policy), h.limiter.PerUser())
+	{
+		productOutlets.GET("", h.require(auth.PermProductRead), h.GetProductOutlets)
+		productOutlets.PUT("/:outletId", h.require(auth.PermProductWrite), h.UpdateProductOutlet)
+	}
+}
+
+func (h *OutletHandler) require(perm auth.Permission) gin.HandlerFunc {
+	return middleware.RequirePermission(h.policy, perm)
+}
+
+func (h *OutletHandler) CreateOutlet(c *gin.Context) {
+	var req dto.CreateOutletRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidRequest(c, err)
+		return
+	}
+
+	res, err := h.usecase.CreateOutlet(c.Request.Context(), req)
+	if err != nil {
+		middleware.Abort(c, err)
+		return
+	}
+
+	respond(c, http.StatusCreated, "success", res)
+}
+
+func (h *OutletHandler) GetAllOutlets(c *gin.Context) {
+	res, err := h.usecase.GetAllOutlets(c.Request.Context())
+	if err != nil {
+		middleware.Abort(c, err)
+		return
+	}
+
+	respond(c, http.StatusOK, "success", res)
+}
+
+func (h *OutletHandler) GetOutletByID(c *gin.Context) {
+	id := c.Param("id")
+
+	res, err := h.usecase.GetOutletByID(c.Request.Context(), id)
+	if err != nil {
+		middleware.Abort(c, err)
+		return
+	}
+
+	respond(c, http.StatusOK, "success", res)
+}
+
+func (h *OutletHandler) UpdateOutlet(c *gin.Context) {
+	id := c.Param("id")
+
+	var req dto.UpdateOutletRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidRequest(c, err)
+		return
+	}
+
+	res, err := h.usecase.UpdateOutlet(c.Request.Context(), id, req)
+	if err != nil {
+		middleware.Abort(c, err)
+		return
+	}
+
+	respond(c, http.StatusOK, "success", res)
+}
+
+func (h *OutletHandler) DeleteOutlet(c *gin.Context) {
+	id := c.Param("id")
+
+	err := h.usecase.DeleteOutlet(c.Request.Context(), id)
+	if err != nil {
+		middleware.Abort(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *OutletHandler) GetProductOutlets(c *gin.Context) {
+	productID := c.Param("id")
+
+	res, err := h.usecase.GetProductOutlets(c.Request.Context(), productID)
+	if err != nil {
+		middleware.Abort(c, err)
+		return
+	}
+
+	respond(c, http.StatusOK, "success", res)
+}
+
+func (h *OutletHandler) UpdateProductOutlet(c *gin.Context) {
+	productID := c.Param("id")
+	outletID := c.Param("outletId")
+
+	var req dto.UpdateProductOutletRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidRequest(c, err)
+		return
+	}
+
+	res, err := h.usecase.UpdateProductOutlet(c.Request.Context(), productID, outletID, req)
+	if err != nil {
+		middleware.Abort(c, err)
+		return
+	}
+
+	respond(c, http.StatusOK, "success", res)
+}
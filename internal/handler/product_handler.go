@@ -1,8 +1,13 @@
 package handler
 
 import (
+	"bufio"
+	"errors"
 	"net/http"
+	"strconv"
 
+	"github.com/dominikuswilly/nofu-be_product/internal/apperror"
+	"github.com/dominikuswilly/nofu-be_product/internal/auth"
 	"github.com/dominikuswilly/nofu-be_product/internal/dto"
 	"github.com/dominikuswilly/nofu-be_product/internal/middleware"
 	"github.com/dominikuswilly/nofu-be_product/internal/usecase"
@@ -10,88 +15,106 @@ import (
 	"go.uber.org/zap"
 )
 
+// maxImageUploadSize limits the size of uploaded product photos
+const maxImageUploadSize = 10 << 20
+
 type ProductHandler struct {
 	usecase        usecase.ProductUsecase
+	changeRequests usecase.ChangeRequestUsecase
 	logger         *zap.Logger
-	authServiceURL string
+	validator      auth.Validator
+	policy         *auth.Policy
+	limiter        *middleware.RateLimiter
+	defaultTenant  string
 }
 
-func NewProductHandler(usecase usecase.ProductUsecase, logger *zap.Logger, authServiceURL string) *ProductHandler {
+func NewProductHandler(usecase usecase.ProductUsecase, changeRequests usecase.ChangeRequestUsecase, logger *zap.Logger, validator auth.Validator, policy *auth.Policy, limiter *middleware.RateLimiter, defaultTenant string) *ProductHandler {
 	return &ProductHandler{
 		usecase:        usecase,
+		changeRequests: changeRequests,
 		logger:         logger,
-		authServiceURL: authServiceURL,
+		validator:      validator,
+		policy:         policy,
+		limiter:        limiter,
+		defaultTenant:  defaultTenant,
 	}
 }
 
 func (h *ProductHandler) RegisterRoutes(r *gin.RouterGroup) {
 	products := r.Group("/products")
-	products.Use(middleware.AuthMiddleware(h.authServiceURL))
+	products.Use(h.limiter.PerIP(middleware.RateGroupAuth), middleware.AuthMiddleware(h.validator), middleware.TenantMiddleware(h.defaultTenant, h.policy), h.limiter.PerUser())
 	{
-		products.POST("", h.CreateProduct)
-		products.GET("", h.GetAllProducts)
-		products.GET("/:id", h.GetProductByID)
-		products.PUT("/:id", h.UpdateProduct)
-		products.DELETE("/:id", h.DeleteProduct)
+		products.POST("", h.require(auth.PermProductWrite), h.CreateProduct)
+		products.GET("", h.require(auth.PermProductRead), h.GetAllProducts)
+		products.GET("/:id", h.require(auth.PermProductRead), h.GetProductByID)
+		products.PUT("/:id", h.require(auth.PermProductWrite), h.UpdateProduct)
+		products.DELETE("/:id", h.require(auth.PermProductDelete), h.DeleteProduct)
+		products.POST("/:id/image", h.require(auth.PermProductWrite), h.UploadProductImage)
+		products.GET("/:id/audit", h.require(auth.PermProductRead), h.GetProductAudit)
+		products.PATCH("/:id/stock", h.require(auth.PermStockAdjust), h.AdjustStock)
+		products.POST("/:id/submit", h.require(auth.PermProductWrite), h.TransitionProduct(usecase.TransitionSubmit))
+		products.POST("/:id/reject", h.require(auth.PermProductPublish), h.TransitionProduct(usecase.TransitionReject))
+		products.POST("/:id/publish", h.require(auth.PermProductPublish), h.TransitionProduct(usecase.TransitionPublish))
+		products.POST("/:id/archive", h.require(auth.PermProductPublish), h.TransitionProduct(usecase.TransitionArchive))
+		products.POST("/:id/restore", h.require(auth.PermProductPublish), h.TransitionProduct(usecase.TransitionRestore))
+		products.GET("/:id/versions", h.require(auth.PermProductRead), h.GetProductVersions)
+		products.GET("/:id/versions/diff", h.require(auth.PermProductRead), h.DiffProductVersions)
+		products.POST("/:id/versions/:version/rollback", h.require(auth.PermProductWrite), h.RollbackProduct)
 	}
 }
 
+func (h *ProductHandler) require(perm auth.Permission) gin.HandlerFunc {
+	return middleware.RequirePermission(h.policy, perm)
+}
+
 func (h *ProductHandler) CreateProduct(c *gin.Context) {
 	var req dto.CreateProductRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		h.logger.Error("Failed to bind JSON", zap.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		invalidRequest(c, err)
 		return
 	}
 
 	res, err := h.usecase.CreateProduct(c.Request.Context(), req)
 	if err != nil {
-		h.logger.Error("Failed to create product", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create product"})
+		middleware.Abort(c, err)
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{
-		"responseCode":    "201",
-		"responseMessage": "success",
-		"data":            res,
-	})
+	respond(c, http.StatusCreated, "success", res)
 }
 
 func (h *ProductHandler) GetAllProducts(c *gin.Context) {
-	res, err := h.usecase.GetAllProducts(c.Request.Context())
+	var query dto.ProductQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		invalidRequest(c, err)
+		return
+	}
+
+	res, err := h.usecase.GetAllProducts(c.Request.Context(), query)
 	if err != nil {
-		h.logger.Error("Failed to fetch products", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch products"})
+		middleware.Abort(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"responseCode":    "200",
-		"responseMessage": "success",
-		"data":            res,
-	})
+	respond(c, http.StatusOK, "success", res)
 }
 
 func (h *ProductHandler) GetProductByID(c *gin.Context) {
 	id := c.Param("id")
 
-	res, err := h.usecase.GetProductByID(c.Request.Context(), id)
-	if err != nil {
-		h.logger.Error("Failed to fetch product", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch product"})
+	var query dto.ProductQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		invalidRequest(c, err)
 		return
 	}
-	if res == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+
+	res, err := h.usecase.GetProductByID(c.Request.Context(), id, query)
+	if err != nil {
+		middleware.Abort(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"responseCode":    "200",
-		"responseMessage": "success",
-		"data":            res,
-	})
+	respond(c, http.StatusOK, "success", res)
 }
 
 func (h *ProductHandler) UpdateProduct(c *gin.Context) {
@@ -99,27 +122,33 @@ func (h *ProductHandler) UpdateProduct(c *gin.Context) {
 
 	var req dto.UpdateProductRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		h.logger.Error("Failed to bind JSON", zap.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		invalidRequest(c, err)
 		return
 	}
 
 	res, err := h.usecase.UpdateProduct(c.Request.Context(), id, req)
+	if errors.Is(err, usecase.ErrReviewRequired) {
+		h.proposeChange(c, id, req)
+		return
+	}
 	if err != nil {
-		h.logger.Error("Failed to update product", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update product"})
+		middleware.Abort(c, err)
 		return
 	}
-	if res == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+
+	respond(c, http.StatusOK, "success", res)
+}
+
+// proposeChange stores an edit to a published product as a change request awaiting review
+func (h *ProductHandler) proposeChange(c *gin.Context, id string, req dto.UpdateProductRequest) {
+	res, err := h.changeRequests.ProposeChange(c.Request.Context(), id, req)
+	if err != nil {
+		middleware.Abort(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"responseCode":    "200",
-		"responseMessage": "success",
-		"data":            res,
-	})
+	// The change goes live once a reviewer approves it
+	respond(c, http.StatusAccepted, "pending review", res)
 }
 
 func (h *ProductHandler) DeleteProduct(c *gin.Context) {
@@ -127,20 +156,139 @@ func (h *ProductHandler) DeleteProduct(c *gin.Context) {
 
 	err := h.usecase.DeleteProduct(c.Request.Context(), id)
 	if err != nil {
-		// Basic check for not found if repository returned specific error,
-		// but here we just assume 500 or 404 based on string match or robust error handling
-		if err.Error() == "product not found" {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		middleware.Abort(c, err)
+		return
+	}
+
+	// 204 responses have no body, so there is no envelope either
+	c.Status(http.StatusNoContent)
+}
+
+func (h *ProductHandler) UploadProductImage(c *gin.Context) {
+	id := c.Param("id")
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImageUploadSize)
+	file, err := c.FormFile("image")
+	if err != nil {
+		middleware.Abort(c, ErrImageRequired.WithDetail("the image field must hold a file of at most 10MB"))
+		return
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		middleware.Abort(c, err)
+		return
+	}
+	defer f.Close()
+
+	// Trust the file content rather than the client supplied Content-Type
+	br := bufio.NewReaderSize(f, 512)
+	head, _ := br.Peek(512)
+	contentType := http.DetectContentType(head)
+	if !usecase.IsSupportedImageType(contentType) {
+		middleware.Abort(c, usecase.ErrUnsupportedImageType.WithDetail("image must be JPEG, PNG, GIF or WebP"))
+		return
+	}
+
+	res, err := h.usecase.UploadProductImage(c.Request.Context(), id, br, contentType)
+	if err != nil {
+		middleware.Abort(c, err)
+		return
+	}
+
+	// Derivatives are generated in the background, so the images map is filled in later
+	respond(c, http.StatusAccepted, "success", res)
+}
+
+func (h *ProductHandler) GetProductAudit(c *gin.Context) {
+	id := c.Param("id")
+
+	res, err := h.usecase.GetProductAudit(c.Request.Context(), id)
+	if err != nil {
+		middleware.Abort(c, err)
+		return
+	}
+
+	respond(c, http.StatusOK, "success", res)
+}
+
+func (h *ProductHandler) AdjustStock(c *gin.Context) {
+	id := c.Param("id")
+
+	var req dto.AdjustStockRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		invalidRequest(c, err)
+		return
+	}
+
+	res, err := h.usecase.AdjustStock(c.Request.Context(), id, req)
+	if err != nil {
+		middleware.Abort(c, err)
+		return
+	}
+
+	respond(c, http.StatusOK, "success", res)
+}
+
+// TransitionProduct returns a handler that applies transition to the product's lifecycle status
+func (h *ProductHandler) TransitionProduct(transition usecase.ProductTransition) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.Param("id")
+
+		res, err := h.usecase.TransitionProduct(c.Request.Context(), id, transition)
+		if err != nil {
+			middleware.Abort(c, err)
 			return
 		}
-		h.logger.Error("Failed to delete product", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete product"})
+
+		respond(c, http.StatusOK, "success", res)
+	}
+}
+
+func (h *ProductHandler) GetProductVersions(c *gin.Context) {
+	id := c.Param("id")
+
+	res, err := h.usecase.GetProductVersions(c.Request.Context(), id)
+	if err != nil {
+		middleware.Abort(c, err)
+		return
+	}
+
+	respond(c, http.StatusOK, "success", res)
+}
+
+func (h *ProductHandler) DiffProductVersions(c *gin.Context) {
+	id := c.Param("id")
+
+	var query dto.ProductVersionDiffQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		invalidRequest(c, err)
+		return
+	}
+
+	res, err := h.usecase.DiffProductVersions(c.Request.Context(), id, query)
+	if err != nil {
+		middleware.Abort(c, err)
+		return
+	}
+
+	respond(c, http.StatusOK, "success", res)
+}
+
+func (h *ProductHandler) RollbackProduct(c *gin.Context) {
+	id := c.Param("id")
+
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil || version < 1 {
+		middleware.Abort(c, ErrValidationFailed.WithViolations(apperror.Violation{Field: "version", Rule: "min", Param: "1"}))
+		return
+	}
+
+	res, err := h.usecase.RollbackProduct(c.Request.Context(), id, version)
+	if err != nil {
+		middleware.Abort(c, err)
 		return
 	}
 
-	c.JSON(http.StatusNoContent, gin.H{
-		"responseCode":    "204",
-		"responseMessage": "success",
-		"data":            nil,
-	})
+	respond(c, http.StatusOK, "success", res)
 }
@@ -0,0 +1,184 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dominikuswilly/nofu-be_product/internal/apperror"
+	"github.com/dominikuswilly/nofu-be_product/internal/dto"
+	"github.com/dominikuswilly/nofu-be_product/internal/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+var (
+	// ErrInvalidRequest is reported when the request body, query or path cannot be parsed
+	ErrInvalidRequest = apperror.Validation("invalid_request", "Invalid request")
+	// ErrValidationFailed is reported when request fields break validation rules
+	ErrValidationFailed = apperror.Validation("validation_failed", "Request validation failed")
+	// ErrImageRequired is reported when an upload has no usable image file
+	ErrImageRequired = apperror.Validation("image_required", "Image file is required")
+)
+
+// dateTimeFormats shows the Go layouts of datetime rules in a form clients understand
+var dateTimeFormats = map[string]string{
+	"15:04":      "HH:MM",
+	"2006-01-02": "YYYY-MM-DD",
+	time.RFC3339: "RFC 3339",
+}
+
+func init() {
+	// Report fields by the names clients send rather than the Go struct field names
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(requestFieldName)
+		v.RegisterStructValidation(validateAvailabilityRule, dto.AvailabilityRuleRequest{})
+	}
+}
+
+// validateAvailabilityRule rejects rules ending before they start. Dates share one layout,
+// so valid ones compare correctly as strings.
+func validateAvailabilityRule(sl validator.StructLevel) {
+	rule := sl.Current().Interface().(dto.AvailabilityRuleRequest)
+	if rule.StartDate != "" && rule.EndDate != "" && rule.EndDate < rule.StartDate {
+		sl.ReportError(rule.EndDate, "endDate", "EndDate", "gtefield", "StartDate")
+	}
+}
+
+// requestFieldName returns the JSON or query name of a request struct field
+func requestFieldName(f reflect.StructField) string {
+	for _, tag := range []string{"json", "form"} {
+		name, _, _ := strings.Cut(f.Tag.Get(tag), ",")
+		if name == "-" {
+			return ""
+		}
+		if name != "" {
+			return name
+		}
+	}
+	return f.Name
+}
+
+// respond writes data in the response envelope
+func respond(c *gin.Context, status int, message string, data interface{}) {
+	c.JSON(status, dto.Response{
+		ResponseCode:    strconv.Itoa(status),
+		ResponseMessage: message,
+		Data:            data,
+	})
+}
+
+// invalidRequest reports a binding failure, listing each invalid field when the request
+// could be decoded but broke validation rules. Binder messages are never passed on, since
+// they are neither translated nor meant for clients.
+func invalidRequest(c *gin.Context, err error) {
+	var (
+		validationErrs validator.ValidationErrors
+		typeErr        *json.UnmarshalTypeError
+		syntaxErr      *json.SyntaxError
+		numErr         *strconv.NumError
+		timeErr        *time.ParseError
+	)
+	switch {
+	case errors.As(err, &validationErrs):
+		violations := make([]apperror.Violation, len(validationErrs))
+		for i, fe := range validationErrs {
+			violations[i] = toViolation(fe)
+		}
+		middleware.Abort(c, ErrValidationFailed.WithViolations(violations...))
+	case errors.As(err, &typeErr):
+		middleware.Abort(c, ErrValidationFailed.WithViolations(apperror.Violation{
+			Field: typeErr.Field,
+			Rule:  "type",
+			Param: jsonTypeName(typeErr.Type),
+		}))
+	case errors.Is(err, io.EOF):
+		middleware.Abort(c, ErrInvalidRequest.WithDetail("request body is empty"))
+	case errors.As(err, &syntaxErr), errors.Is(err, io.ErrUnexpectedEOF):
+		middleware.Abort(c, ErrInvalidRequest.WithDetail("request body is not valid JSON"))
+	case errors.As(err, &numErr):
+		middleware.Abort(c, ErrValidationFailed.WithViolations(apperror.Violation{
+			Field: queryField(c, numErr.Num),
+			Rule:  "type",
+			Param: "number",
+		}))
+	case errors.As(err, &timeErr):
+		format, ok := dateTimeFormats[timeErr.Layout]
+		if !ok {
+			format = timeErr.Layout
+		}
+		middleware.Abort(c, ErrValidationFailed.WithViolations(apperror.Violation{
+			Field: queryField(c, timeErr.Value),
+			Rule:  "datetime",
+			Param: format,
+		}))
+	default:
+		middleware.Abort(c, ErrInvalidRequest)
+	}
+}
+
+// queryField names the query parameter holding value. Form binding errors do not say which
+// field they are about, so it is found by the value that failed to parse.
+func queryField(c *gin.Context, value string) string {
+	for name, values := range c.Request.URL.Query() {
+		for _, v := range values {
+			if v == value {
+				return name
+			}
+		}
+	}
+	return "query"
+}
+
+func toViolation(fe validator.FieldError) apperror.Violation {
+	// The namespace starts with the request struct name, e.g. CreateProductRequest.availability[0].startTime
+	_, field, _ := strings.Cut(fe.Namespace(), ".")
+	param := fe.Param()
+	switch fe.Tag() {
+	case "datetime":
+		if format, ok := dateTimeFormats[param]; ok {
+			param = format
+		}
+	case "required_with", "gtefield":
+		param = lowerFirst(param)
+	}
+
+	return apperror.Violation{
+		Field:  field,
+		Rule:   fe.Tag(),
+		Param:  param,
+		Length: fe.Kind() == reflect.String,
+	}
+}
+
+// jsonTypeName names the JSON type that decodes into t
+func jsonTypeName(t reflect.Type) string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Struct, reflect.Map:
+		return "object"
+	default:
+		return "number"
+	}
+}
+
+// lowerFirst turns a Go field name referenced by a rule into its JSON name, e.g. EndTime into endTime
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToLower(s[:1]) + s[1:]
+}
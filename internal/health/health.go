@@ -0,0 +1,109 @@
+// Package health checks whether the service and its dependencies can serve traffic
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Check reports whether a dependency is usable; it must give up when ctx is done
+type Check func(ctx context.Context) error
+
+// Dependency statuses
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+// Result is the outcome of one dependency check. The error is for logs only, since it can
+// name hosts and addresses that unauthenticated probe callers must not see.
+type Result struct {
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latencyMs"`
+	Err       error   `json:"-"`
+}
+
+// Report is the readiness of the service with the result of every dependency check
+type Report struct {
+	Ready bool `json:"ready"`
+	// ShuttingDown is set once the service stopped accepting new work
+	ShuttingDown bool              `json:"shuttingDown,omitempty"`
+	Checks       map[string]Result `json:"checks"`
+}
+
+// Checker runs the readiness checks of the service's dependencies
+type Checker struct {
+	timeout      time.Duration
+	checks       map[string]Check
+	shuttingDown atomic.Bool
+}
+
+// NewChecker creates a Checker that gives every check at most timeout
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout, checks: map[string]Check{}}
+}
+
+// Add registers the check of a dependency the service cannot serve traffic without. It must
+// be called before the checker is used.
+func (c *Checker) Add(name string, check Check) {
+	c.checks[name] = check
+}
+
+// SetShuttingDown makes the service report not ready from now on, so that load balancers
+// stop sending requests before the server drains its connections
+func (c *Checker) SetShuttingDown() {
+	c.shuttingDown.Store(true)
+}
+
+// Ready runs all checks concurrently and reports whether every dependency is up
+func (c *Checker) Ready(ctx context.Context) Report {
+	report := Report{Ready: true, Checks: make(map[string]Result, len(c.checks))}
+	if c.shuttingDown.Load() {
+		report.Ready, report.ShuttingDown = false, true
+		return report
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, check := range c.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result := run(ctx, check)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[name] = result
+			if result.Status != StatusUp {
+				report.Ready = false
+			}
+		}()
+	}
+	wg.Wait()
+	return report
+}
+
+// run runs check, giving up when ctx is done even if check ignores it
+func run(ctx context.Context, check Check) Result {
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() { done <- check(ctx) }()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	result := Result{Status: StatusUp, LatencyMs: float64(time.Since(start).Microseconds()) / 1000}
+	if err != nil {
+		result.Status, result.Err = StatusDown, err
+	}
+	return result
+}
@@ -0,0 +1,83 @@
+// Package i18n translates the messages returned to API clients into English and Indonesian
+package i18n
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Lang is a supported response language
+type Lang string
+
+const (
+	English    Lang = "en"
+	Indonesian Lang = "id"
+)
+
+// Default is used when the client accepts none of the supported languages
+const Default = English
+
+// FromAcceptLanguage picks the supported language the client prefers most in an
+// Accept-Language header, e.g. "id-ID,id;q=0.9,en;q=0.8" selects Indonesian
+func FromAcceptLanguage(header string) Lang {
+	best, bestQ := Default, 0.0
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+
+		primary, _, _ := strings.Cut(strings.ToLower(tag), "-")
+		lang := Lang(primary)
+		if _, ok := messages[lang]; ok && q > bestQ {
+			best, bestQ = lang, q
+		}
+	}
+	return best
+}
+
+// Title returns the title of the error code in lang, or fallback when it has no translation
+func Title(lang Lang, code, fallback string) string {
+	if msg, ok := lookup(lang, "title."+code); ok {
+		return msg
+	}
+	return fallback
+}
+
+// FieldMessage describes a broken validation rule of field in lang. length selects the
+// wording for rules that limit the length of a text rather than its value.
+func FieldMessage(lang Lang, field, rule, param string, length bool) string {
+	keys := []string{"field." + rule, "field.invalid"}
+	switch {
+	case rule == "type":
+		keys[0] += "." + param
+	case length:
+		keys = append([]string{"field." + rule + ".length"}, keys...)
+	}
+
+	var msg string
+	for _, key := range keys {
+		if m, ok := lookup(lang, key); ok {
+			msg = m
+			break
+		}
+	}
+	if rule == "oneof" {
+		param = strings.Join(strings.Fields(param), ", ")
+	}
+	return strings.NewReplacer("{field}", field, "{param}", param).Replace(msg)
+}
+
+// lookup finds key in lang, falling back to the default language
+func lookup(lang Lang, key string) (string, bool) {
+	if msg, ok := messages[lang][key]; ok {
+		return msg, true
+	}
+	msg, ok := messages[Default][key]
+	return msg, ok
+}
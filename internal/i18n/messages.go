@@ -0,0 +1,81 @@
+package i18n
+
+// messages holds the translations per language. Titles are keyed by error code; English
+// titles are the ones the errors are declared with. Field messages are keyed by rule, with
+// {field} and {param} replaced by the field path and rule argument.
+var messages = map[Lang]map[string]string{
+	English: {
+		"field.invalid":       "{field} is invalid",
+		"field.required":      "{field} is required",
+		"field.required_with": "{field} is required when {param} is set",
+		"field.min":           "{field} must be at least {param}",
+		"field.min.length":    "{field} must contain at least {param} characters",
+		"field.max":           "{field} must be at most {param}",
+		"field.max.length":    "{field} must contain at most {param} characters",
+		"field.gt":            "{field} must be greater than {param}",
+		"field.gte":           "{field} must be greater than or equal to {param}",
+		"field.gtefield":      "{field} must not be before {param}",
+		"field.lt":            "{field} must be less than {param}",
+		"field.lte":           "{field} must be less than or equal to {param}",
+		"field.ne":            "{field} must not be {param}",
+		"field.oneof":         "{field} must be one of: {param}",
+		"field.datetime":      "{field} must have the format {param}",
+		"field.timezone":      "{field} must be an IANA time zone such as Asia/Jakarta",
+		"field.type.number":   "{field} must be a number",
+		"field.type.string":   "{field} must be a text",
+		"field.type.boolean":  "{field} must be true or false",
+		"field.type.array":    "{field} must be a list",
+		"field.type.object":   "{field} must be an object",
+	},
+	Indonesian: {
+		"title.validation_failed":         "Validasi permintaan gagal",
+		"title.invalid_request":           "Permintaan tidak valid",
+		"title.image_required":            "Berkas gambar wajib diunggah",
+		"title.unsupported_image_type":    "Jenis gambar tidak didukung",
+		"title.image_too_large":           "Dimensi gambar terlalu besar",
+		"title.empty_change_request":      "Permintaan perubahan tidak berisi perubahan",
+		"title.tenant_required":           "Tenant wajib diisi",
+		"title.invalid_tenant":            "ID tenant tidak valid",
+		"title.unauthorized":              "Tidak terautentikasi",
+		"title.missing_permission":        "Izin tidak mencukupi",
+		"title.tenant_mismatch":           "Token tidak berlaku untuk tenant ini",
+		"title.cors_origin_not_allowed":   "Origin tidak diizinkan",
+		"title.review_required":           "Perubahan pada produk yang sudah terbit harus ditinjau",
+		"title.product_not_found":         "Produk tidak ditemukan",
+		"title.outlet_not_found":          "Outlet tidak ditemukan",
+		"title.change_request_not_found":  "Permintaan perubahan tidak ditemukan",
+		"title.notification_not_found":    "Notifikasi tidak ditemukan",
+		"title.product_version_not_found": "Versi produk tidak ditemukan",
+		"title.route_not_found":           "Rute tidak ditemukan",
+		"title.product_already_exists":    "Produk sudah ada",
+		"title.insufficient_stock":        "Stok tidak mencukupi",
+		"title.invalid_status_transition": "Perubahan status tidak diizinkan",
+		"title.product_status_changed":    "Status produk telah berubah",
+		"title.change_request_closed":     "Permintaan perubahan sudah tidak menunggu tinjauan",
+		"title.rate_limited":              "Terlalu banyak permintaan",
+		"title.auth_unavailable":          "Layanan autentikasi tidak tersedia",
+		"title.not_ready":                 "Layanan belum siap",
+		"title.internal_error":            "Terjadi kesalahan pada server",
+		"field.invalid":                   "{field} tidak valid",
+		"field.required":                  "{field} wajib diisi",
+		"field.required_with":             "{field} wajib diisi jika {param} diisi",
+		"field.min":                       "{field} minimal {param}",
+		"field.min.length":                "{field} minimal {param} karakter",
+		"field.max":                       "{field} maksimal {param}",
+		"field.max.length":                "{field} maksimal {param} karakter",
+		"field.gt":                        "{field} harus lebih besar dari {param}",
+		"field.gte":                       "{field} harus lebih besar dari atau sama dengan {param}",
+		"field.gtefield":                  "{field} tidak boleh sebelum {param}",
+		"field.lt":                        "{field} harus lebih kecil dari {param}",
+		"field.lte":                       "{field} harus lebih kecil dari atau sama dengan {param}",
+		"field.ne":                        "{field} tidak boleh {param}",
+		"field.oneof":                     "{field} harus salah satu dari: {param}",
+		"field.datetime":                  "{field} harus berformat {param}",
+		"field.timezone":                  "{field} harus zona waktu IANA seperti Asia/Jakarta",
+		"field.type.number":               "{field} harus berupa angka",
+		"field.type.string":               "{field} harus berupa teks",
+		"field.type.boolean":              "{field} harus bernilai true atau false",
+		"field.type.array":                "{field} harus berupa daftar",
+		"field.type.object":               "{field} harus berupa objek",
+	},
+}
@@ -0,0 +1,259 @@
+package imaging
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/HugoSmits86/nativewebp"
+	"github.com/dominikuswilly/nofu-be_product/internal/apperror"
+	"github.com/dominikuswilly/nofu-be_product/internal/entity"
+	"github.com/dominikuswilly/nofu-be_product/internal/repository"
+	"github.com/dominikuswilly/nofu-be_product/internal/storage"
+	"github.com/dominikuswilly/nofu-be_product/internal/tenant"
+	"go.uber.org/zap"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// Size describes a derivative rendition, bounded by its maximum width
+type Size struct {
+	Name     string
+	MaxWidth int
+}
+
+// DefaultSizes are the renditions generated for every product photo
+var DefaultSizes = []Size{
+	{Name: "thumbnail", MaxWidth: 160},
+	{Name: "card", MaxWidth: 480},
+	{Name: "detail", MaxWidth: 1080},
+}
+
+const (
+	jpegQuality = 85
+	jobTimeout  = 2 * time.Minute
+	// systemActor is recorded as the author of the revisions the worker makes
+	systemActor = "system"
+)
+
+// ErrTooManyPixels is reported for images whose dimensions exceed the pixel limit. A small
+// file can declare huge dimensions and would take gigabytes of memory to decode.
+var ErrTooManyPixels = apperror.Validation("image_too_large", "Image dimensions too large")
+
+type job struct {
+	tenantID    string
+	productID   string
+	originalKey string
+}
+
+// Worker generates resized JPEG and WebP derivatives of uploaded product images in the background
+type Worker struct {
+	repo      repository.ProductRepository
+	versions  repository.ProductVersionRepository
+	tx        repository.Transactor
+	storage   storage.Storage
+	logger    *zap.Logger
+	sizes     []Size
+	maxPixels int
+	jobs      chan job
+	wg        sync.WaitGroup
+}
+
+// NewWorker creates a new Worker with room for queueSize pending jobs. Images of more than
+// maxPixels pixels are rejected. Saving the derivatives records a revision in versions.
+func NewWorker(repo repository.ProductRepository, versions repository.ProductVersionRepository, tx repository.Transactor, storage storage.Storage, logger *zap.Logger, queueSize, maxPixels int) *Worker {
+	return &Worker{
+		repo:      repo,
+		versions:  versions,
+		tx:        tx,
+		storage:   storage,
+		logger:    logger,
+		sizes:     DefaultSizes,
+		maxPixels: maxPixels,
+		jobs:      make(chan job, queueSize),
+	}
+}
+
+// CheckSize reads the header of the image in r and rejects it with ErrTooManyPixels when
+// it has more pixels than the worker accepts
+func (w *Worker) CheckSize(r io.Reader) error {
+	cfg, _, err := image.DecodeConfig(r)
+	if err != nil {
+		return fmt.Errorf("failed to read image header: %w", err)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width > w.maxPixels/cfg.Height {
+		return ErrTooManyPixels.WithDetail(fmt.Sprintf("the image is %dx%d pixels, at most %d pixels are allowed", cfg.Width, cfg.Height, w.maxPixels))
+	}
+	return nil
+}
+
+// Start runs concurrency workers until ctx is cancelled
+func (w *Worker) Start(ctx context.Context, concurrency int) {
+	for i := 0; i < concurrency; i++ {
+		w.wg.Add(1)
+		go func() {
+			defer w.wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case j := <-w.jobs:
+					w.process(j)
+				}
+			}
+		}()
+	}
+}
+
+// Wait blocks until all workers have finished their in-flight job
+func (w *Worker) Wait() {
+	w.wg.Wait()
+}
+
+// Enqueue schedules derivative generation for the original image stored under originalKey,
+// waiting for room in the queue until ctx is done. The job runs for the tenant of ctx.
+func (w *Worker) Enqueue(ctx context.Context, productID, originalKey string) error {
+	tenantID, _ := tenant.FromContext(ctx)
+
+	select {
+	case w.jobs <- job{tenantID: tenantID, productID: productID, originalKey: originalKey}:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("failed to enqueue image job: %w", ctx.Err())
+	}
+}
+
+func (w *Worker) process(j job) {
+	// In-flight jobs are allowed to finish during shutdown, so they do not use the worker context
+	ctx, cancel := context.WithTimeout(tenant.WithID(context.Background(), j.tenantID), jobTimeout)
+	defer cancel()
+
+	logger := w.logger.With(zap.String("tenant_id", j.tenantID), zap.String("product_id", j.productID), zap.String("key", j.originalKey))
+
+	images, err := w.generate(ctx, j.originalKey)
+	if err != nil {
+		logger.Error("Failed to generate image derivatives", zap.Error(err))
+		return
+	}
+
+	if err := w.save(ctx, j.productID, w.storage.URL(j.originalKey), images); err != nil {
+		logger.Error("Failed to save image derivatives", zap.Error(err))
+		return
+	}
+	logger.Info("Generated image derivatives", zap.Int("count", len(images)))
+}
+
+// save stores the derivatives of the photo at sourceUrl together with a revision of the
+// product. Nothing is recorded when the photo was replaced or the product deleted meanwhile.
+func (w *Worker) save(ctx context.Context, productID, sourceUrl string, images map[string]entity.ProductImage) error {
+	return w.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := w.repo.UpdateImages(ctx, productID, sourceUrl, images); err != nil {
+			return err
+		}
+		product, err := w.repo.GetByID(ctx, productID)
+		if err != nil {
+			return err
+		}
+		if product == nil || product.Url != sourceUrl {
+			return nil
+		}
+		return w.versions.Create(ctx, &entity.ProductVersion{
+			ProductID: product.ID,
+			Action:    entity.AuditActionImages,
+			ActorID:   systemActor,
+			Snapshot:  *product,
+			CreatedAt: time.Now(),
+		})
+	})
+}
+
+func (w *Worker) generate(ctx context.Context, originalKey string) (map[string]entity.ProductImage, error) {
+	r, err := w.storage.Get(ctx, originalKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read original image: %w", err)
+	}
+	data, err := io.ReadAll(r)
+	r.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read original image: %w", err)
+	}
+	// Check the declared dimensions before decoding allocates memory for them
+	if err := w.CheckSize(bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode original image: %w", err)
+	}
+
+	base := strings.TrimSuffix(originalKey, path.Ext(originalKey))
+	images := make(map[string]entity.ProductImage, len(w.sizes))
+	for _, size := range w.sizes {
+		scaled := resize(src, size.MaxWidth)
+
+		jpegKey := base + "-" + size.Name + ".jpg"
+		if err := w.put(ctx, jpegKey, "image/jpeg", func(buf *bytes.Buffer) error {
+			return jpeg.Encode(buf, flatten(scaled), &jpeg.Options{Quality: jpegQuality})
+		}); err != nil {
+			return nil, err
+		}
+
+		webpKey := base + "-" + size.Name + ".webp"
+		if err := w.put(ctx, webpKey, "image/webp", func(buf *bytes.Buffer) error {
+			return nativewebp.Encode(buf, scaled, nil)
+		}); err != nil {
+			return nil, err
+		}
+
+		images[size.Name] = entity.ProductImage{
+			Url:     w.storage.URL(jpegKey),
+			WebpUrl: w.storage.URL(webpKey),
+			Width:   scaled.Bounds().Dx(),
+			Height:  scaled.Bounds().Dy(),
+		}
+	}
+	return images, nil
+}
+
+func (w *Worker) put(ctx context.Context, key, contentType string, encode func(*bytes.Buffer) error) error {
+	var buf bytes.Buffer
+	if err := encode(&buf); err != nil {
+		return fmt.Errorf("failed to encode %s: %w", key, err)
+	}
+	if err := w.storage.Put(ctx, key, &buf, contentType); err != nil {
+		return fmt.Errorf("failed to store %s: %w", key, err)
+	}
+	return nil
+}
+
+// resize scales src down to maxWidth, preserving the aspect ratio. Images are never upscaled.
+func resize(src image.Image, maxWidth int) *image.RGBA {
+	b := src.Bounds()
+	width, height := b.Dx(), b.Dy()
+	if width > maxWidth {
+		height = max(1, height*maxWidth/width)
+		width = maxWidth
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, b, draw.Src, nil)
+	return dst
+}
+
+// flatten composites img onto a white background, since JPEG has no alpha channel
+func flatten(img *image.RGBA) *image.RGBA {
+	dst := image.NewRGBA(img.Bounds())
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), img, img.Bounds().Min, draw.Over)
+	return dst
+}
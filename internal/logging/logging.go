@@ -0,0 +1,47 @@
+// Package logging carries the request ID and a request-scoped logger through contexts
+package logging
+
+import (
+	"context"
+	"regexp"
+
+	"go.uber.org/zap"
+)
+
+// RequestIDHeader is the header that carries the request ID between clients and services
+const RequestIDHeader = "X-Request-ID"
+
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// ValidRequestID reports whether id is safe to accept from a client and repeat in logs
+func ValidRequestID(id string) bool {
+	return validRequestID.MatchString(id)
+}
+
+type requestIDKey struct{}
+
+type loggerKey struct{}
+
+// WithRequestID returns a copy of ctx carrying the request ID
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns the request ID stored in ctx, or "" outside a request
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// WithLogger returns a copy of ctx carrying logger
+func WithLogger(ctx context.Context, logger *zap.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// FromContext returns the logger stored in ctx, falling back to the global logger
+func FromContext(ctx context.Context) *zap.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*zap.Logger); ok {
+		return logger
+	}
+	return zap.L()
+}
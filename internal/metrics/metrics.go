@@ -0,0 +1,252 @@
+// Package metrics exposes the Prometheus metrics of the service: HTTP traffic, repository
+// queries, token validation, rate limiting, database connection pools and product stock.
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/dominikuswilly/nofu-be_product/internal/apperror"
+	"github.com/dominikuswilly/nofu-be_product/internal/auth"
+	"github.com/dominikuswilly/nofu-be_product/internal/repository"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.uber.org/zap"
+)
+
+const namespace = "nofu_product"
+
+// Auth validation outcomes
+const (
+	AuthOK          = "ok"
+	AuthMissing     = "missing"
+	AuthInvalid     = "invalid"
+	AuthUnavailable = "unavailable"
+)
+
+// Rate limit decisions
+const (
+	RateLimitAllowed = "allowed"
+	RateLimitLimited = "limited"
+	RateLimitError   = "error"
+)
+
+// Registry holds every metric of the service. It is separate from the default registry so
+// that libraries cannot add metrics behind our back.
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests handled, by method, route and status.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Time taken to handle HTTP requests, by method, route and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	httpInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "http_requests_in_flight",
+		Help:      "HTTP requests currently being handled.",
+	})
+
+	queryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "repository_query_duration_seconds",
+		Help:      "Time taken by repository calls, by repository, method and outcome (ok, rejected or error).",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"repository", "method", "outcome"})
+
+	authValidations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "auth_validations_total",
+		Help:      "Bearer token validations, by outcome (ok, missing, invalid or unavailable).",
+	}, []string{"outcome"})
+
+	authDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "auth_validation_duration_seconds",
+		Help:      "Time taken to validate bearer tokens, by outcome.",
+		Buckets:   []float64{.0005, .001, .005, .01, .025, .05, .1, .25, .5, 1, 3},
+	}, []string{"outcome"})
+
+	rateLimitDecisions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rate_limit_decisions_total",
+		Help:      "Rate limit decisions, by route group and outcome (allowed, limited or error).",
+	}, []string{"group", "outcome"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests, httpDuration, httpInFlight,
+		queryDuration,
+		authValidations, authDuration,
+		rateLimitDecisions,
+	)
+}
+
+// Handler serves the metrics in the Prometheus exposition format. A failing collector is
+// logged and left out rather than failing the whole scrape.
+func Handler(logger *zap.Logger) http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{
+		ErrorLog:      zap.NewStdLog(logger),
+		ErrorHandling: promhttp.ContinueOnError,
+	})
+}
+
+// RequestStarted counts a request as in flight and returns the function that records it
+// once handled
+func RequestStarted() func(method, route string, status int) {
+	start := time.Now()
+	httpInFlight.Inc()
+	return func(method, route string, status int) {
+		httpInFlight.Dec()
+		code := strconv.Itoa(status)
+		httpRequests.WithLabelValues(method, route, code).Inc()
+		httpDuration.WithLabelValues(method, route, code).Observe(time.Since(start).Seconds())
+	}
+}
+
+// ObserveAuth records a token validation. d is zero when no validation was attempted.
+func ObserveAuth(outcome string, d time.Duration) {
+	authValidations.WithLabelValues(outcome).Inc()
+	if d > 0 {
+		authDuration.WithLabelValues(outcome).Observe(d.Seconds())
+	}
+}
+
+// ObserveRateLimit records a rate limit decision for a request to group
+func ObserveRateLimit(group, outcome string) {
+	rateLimitDecisions.WithLabelValues(group, outcome).Inc()
+}
+
+// QueryHook records the duration of repository calls. Calls that fail with an application
+// error, such as a missing product, are recorded as rejected rather than as errors.
+func QueryHook(ctx context.Context, repo, method string) (context.Context, func(err error)) {
+	start := time.Now()
+	return ctx, func(err error) {
+		outcome := "ok"
+		var appErr *apperror.Error
+		switch {
+		case errors.As(err, &appErr):
+			outcome = "rejected"
+		case err != nil:
+			outcome = "error"
+		}
+		queryDuration.WithLabelValues(repo, method, outcome).Observe(time.Since(start).Seconds())
+	}
+}
+
+// RegisterAuthRemote exposes the token cache and circuit breaker statistics of the remote
+// token validator
+func RegisterAuthRemote(stats *auth.RemoteStats) error {
+	counter := func(name, help string, v interface{ Load() int64 }) prometheus.Collector {
+		return prometheus.NewCounterFunc(prometheus.CounterOpts{Namespace: namespace, Name: name, Help: help},
+			func() float64 { return float64(v.Load()) })
+	}
+	for _, c := range []prometheus.Collector{
+		counter("auth_cache_hits_total", "Token validations answered from the cache.", &stats.CacheHits),
+		counter("auth_cache_misses_total", "Token validations not found in the cache.", &stats.CacheMisses),
+		counter("auth_remote_failures_total", "Calls to the auth service that failed with an outage.", &stats.Failures),
+		counter("auth_breaker_rejections_total", "Token validations failed fast by the open circuit breaker.", &stats.Rejected),
+		counter("auth_breaker_opens_total", "Times the circuit breaker opened.", &stats.BreakerOpens),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "auth_breaker_state",
+			Help:      "State of the circuit breaker: 0 closed, 1 open, 2 half-open.",
+		}, func() float64 { return float64(stats.BreakerState()) }),
+	} {
+		if err := Registry.Register(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RegisterDB exposes the connection pool statistics of db, labelled with name
+func RegisterDB(db *sql.DB, name string) error {
+	return Registry.Register(collectors.NewDBStatsCollector(db, name))
+}
+
+// RegisterProducts exposes the number of published and low-stock products per tenant,
+// counted by products within timeout. The counts are reused by scrapes within cacheTTL of
+// them, so frequent scrapes do not query the database each time.
+func RegisterProducts(products repository.ProductRepository, lowStock int64, timeout, cacheTTL time.Duration) error {
+	return Registry.Register(&productCollector{products: products, lowStock: lowStock, timeout: timeout, cacheTTL: cacheTTL})
+}
+
+var (
+	activeProductsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "products_active"),
+		"Published products, by tenant.",
+		[]string{"tenant"}, nil,
+	)
+	lowStockProductsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "products_low_stock"),
+		"Published products at or below the low stock threshold, by tenant.",
+		[]string{"tenant"}, nil,
+	)
+)
+
+// productCollector counts products at scrape time, at most once per cacheTTL, so the gauges
+// never drift from the data by more than that
+type productCollector struct {
+	products repository.ProductRepository
+	lowStock int64
+	timeout  time.Duration
+	cacheTTL time.Duration
+
+	mu        sync.Mutex
+	counts    []repository.ProductCounts
+	countedAt time.Time
+}
+
+func (c *productCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- activeProductsDesc
+	ch <- lowStockProductsDesc
+}
+
+func (c *productCollector) Collect(ch chan<- prometheus.Metric) {
+	counts, err := c.count()
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(activeProductsDesc, err)
+		return
+	}
+	for _, tc := range counts {
+		ch <- prometheus.MustNewConstMetric(activeProductsDesc, prometheus.GaugeValue, float64(tc.Active), tc.TenantID)
+		ch <- prometheus.MustNewConstMetric(lowStockProductsDesc, prometheus.GaugeValue, float64(tc.LowStock), tc.TenantID)
+	}
+}
+
+// count returns the cached counts while they are fresh and counts again otherwise. Failures
+// are not cached, so the next scrape retries.
+func (c *productCollector) count() ([]repository.ProductCounts, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.countedAt.IsZero() && time.Since(c.countedAt) < c.cacheTTL {
+		return c.counts, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+	counts, err := c.products.CountByTenant(ctx, c.lowStock)
+	if err != nil {
+		return nil, err
+	}
+	c.counts, c.countedAt = counts, time.Now()
+	return counts, nil
+}
@@ -0,0 +1,108 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/dominikuswilly/nofu-be_product/internal/auth"
+	"github.com/dominikuswilly/nofu-be_product/internal/logging"
+	"github.com/dominikuswilly/nofu-be_product/internal/tenant"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// RequestIDKey is the gin context key under which the request ID is stored
+const RequestIDKey = "requestId"
+
+// sensitiveHeaders are never written to the logs
+var sensitiveHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+	"Set-Cookie":          true,
+	"X-Api-Key":           true,
+}
+
+// AccessLog assigns every request an ID, reusing a well-formed X-Request-ID from the client,
+// and returns it in the X-Request-ID response header. It stores a logger tagged with the ID,
+// and with the trace ID when the request is traced, in the request context.
+//
+// Once the request is handled it logs one line with the route, status, latency, user and
+// tenant. Request headers are included at debug level, with credentials redacted.
+func AccessLog(logger *zap.Logger) gin.HandlerFunc {
+	// A stack trace of the middleware says nothing about why a request failed
+	accessLogger := logger.WithOptions(zap.AddStacktrace(zapcore.FatalLevel))
+
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		requestID := c.GetHeader(logging.RequestIDHeader)
+		if !logging.ValidRequestID(requestID) {
+			requestID = uuid.NewString()
+		}
+		c.Set(RequestIDKey, requestID)
+		c.Header(logging.RequestIDHeader, requestID)
+
+		ids := []zap.Field{zap.String("requestId", requestID)}
+		if span := trace.SpanContextFromContext(c.Request.Context()); span.IsValid() {
+			ids = append(ids, zap.String("traceId", span.TraceID().String()))
+		}
+		reqLogger := logger.With(ids...)
+		ctx := logging.WithRequestID(c.Request.Context(), requestID)
+		c.Request = c.Request.WithContext(logging.WithLogger(ctx, reqLogger))
+
+		c.Next()
+
+		// Later middleware replaces c.Request to add the principal and tenant
+		ctx = c.Request.Context()
+		status := c.Writer.Status()
+		fields := []zap.Field{
+			zap.String("method", c.Request.Method),
+			zap.String("route", c.FullPath()),
+			zap.String("path", c.Request.URL.Path),
+			zap.Int("status", status),
+			zap.Duration("latency", time.Since(start)),
+			zap.Int("bytes", c.Writer.Size()),
+			zap.String("clientIp", c.ClientIP()),
+			zap.String("userAgent", c.Request.UserAgent()),
+		}
+		if p := auth.PrincipalFromContext(ctx); p != nil {
+			fields = append(fields, zap.String("user", p.UserID))
+		}
+		if tenantID, ok := tenant.FromContext(ctx); ok {
+			fields = append(fields, zap.String("tenant", tenantID))
+		}
+		if accessLogger.Core().Enabled(zapcore.DebugLevel) {
+			fields = append(fields, zap.Any("headers", redactHeaders(c.Request.Header)))
+		}
+
+		line := accessLogger.With(ids...)
+		switch {
+		case status >= http.StatusInternalServerError:
+			line.Error("Request handled", fields...)
+		case status >= http.StatusBadRequest:
+			line.Warn("Request handled", fields...)
+		default:
+			line.Info("Request handled", fields...)
+		}
+	}
+}
+
+// redactHeaders flattens h for logging, masking the values of sensitiveHeaders
+func redactHeaders(h http.Header) map[string]string {
+	redacted := make(map[string]string, len(h))
+	for name, values := range h {
+		if len(values) == 0 {
+			continue
+		}
+		if sensitiveHeaders[http.CanonicalHeaderKey(name)] {
+			redacted[name] = "[REDACTED]"
+			continue
+		}
+		redacted[name] = values[0]
+	}
+	return redacted
+}
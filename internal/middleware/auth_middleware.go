@@ -1,46 +1,87 @@
 package middleware
 
 import (
-	"bytes"
-	"net/http"
+	"context"
+	"errors"
+	"strings"
+	"time"
 
+	"github.com/dominikuswilly/nofu-be_product/internal/apperror"
+	"github.com/dominikuswilly/nofu-be_product/internal/auth"
+	"github.com/dominikuswilly/nofu-be_product/internal/metrics"
+	"github.com/dominikuswilly/nofu-be_product/internal/tracing"
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
 )
 
-func AuthMiddleware(authServiceURL string) gin.HandlerFunc {
+// PrincipalKey is the gin context key under which the authenticated *auth.Principal is stored
+const PrincipalKey = "principal"
+
+var (
+	// ErrUnauthorized is reported for requests without a valid bearer token
+	ErrUnauthorized = apperror.Unauthorized("unauthorized", "Unauthorized")
+	// ErrAuthUnavailable is reported when tokens cannot be verified
+	ErrAuthUnavailable = apperror.Unavailable("auth_unavailable", "Authentication service unavailable")
+)
+
+func AuthMiddleware(validator auth.Validator) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header is missing"})
-			c.Abort()
+			metrics.ObserveAuth(metrics.AuthMissing, 0)
+			Abort(c, ErrUnauthorized.WithDetail("Authorization header is missing"))
 			return
 		}
 
-		client := &http.Client{}
-		req, err := http.NewRequest("POST", authServiceURL, bytes.NewBuffer([]byte("{}")))
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create request"})
-			c.Abort()
+		// The scheme name is case-insensitive (RFC 9110 section 11.1)
+		scheme, token, _ := strings.Cut(authHeader, " ")
+		token = strings.TrimLeft(token, " ")
+		if !strings.EqualFold(scheme, "Bearer") || token == "" {
+			metrics.ObserveAuth(metrics.AuthMissing, 0)
+			Abort(c, ErrUnauthorized.WithDetail("Authorization header must be a Bearer token"))
 			return
 		}
 
-		req.Header.Set("Authorization", authHeader)
-		req.Header.Set("Content-Type", "application/json")
-
-		resp, err := client.Do(req)
+		principal, err := validateToken(c.Request.Context(), validator, token)
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Failed to validate token"})
-			c.Abort()
-			return
-		}
-		defer resp.Body.Close()
-
-		if resp.StatusCode != http.StatusOK {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
-			c.Abort()
+			// Fail fast instead of rejecting valid users while the auth service is down
+			if errors.Is(err, auth.ErrUnavailable) {
+				Abort(c, ErrAuthUnavailable.Wrap(err))
+				return
+			}
+			Abort(c, ErrUnauthorized.Wrap(err))
 			return
 		}
 
+		c.Set(PrincipalKey, principal)
+		c.Request = c.Request.WithContext(auth.WithPrincipal(c.Request.Context(), principal))
 		c.Next()
 	}
 }
+
+// validateToken validates token in a span of its own, recording the outcome and latency
+func validateToken(ctx context.Context, validator auth.Validator, token string) (*auth.Principal, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "auth.Validate")
+	defer span.End()
+
+	start := time.Now()
+	principal, err := validator.Validate(ctx, token)
+	elapsed := time.Since(start)
+
+	outcome := metrics.AuthOK
+	switch {
+	case errors.Is(err, auth.ErrUnavailable):
+		outcome = metrics.AuthUnavailable
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	case err != nil:
+		outcome = metrics.AuthInvalid
+	default:
+		span.SetAttributes(semconv.EnduserID(principal.UserID))
+	}
+	span.SetAttributes(attribute.String("auth.outcome", outcome))
+	metrics.ObserveAuth(outcome, elapsed)
+	return principal, err
+}
@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dominikuswilly/nofu-be_product/internal/auth"
+	"github.com/gin-gonic/gin"
+)
+
+// tokenValidator accepts only its token
+type tokenValidator string
+
+func (v tokenValidator) Validate(_ context.Context, token string) (*auth.Principal, error) {
+	if token != string(v) {
+		return nil, errors.New("unknown token")
+	}
+	return &auth.Principal{UserID: "u1"}, nil
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		header     string
+		wantStatus int
+	}{
+		{"bearer token", "Bearer s3cret", http.StatusOK},
+		{"lowercase scheme", "bearer s3cret", http.StatusOK},
+		{"uppercase scheme", "BEARER s3cret", http.StatusOK},
+		{"several spaces", "Bearer   s3cret", http.StatusOK},
+		{"missing header", "", http.StatusUnauthorized},
+		{"scheme only", "Bearer", http.StatusUnauthorized},
+		{"scheme and space only", "Bearer ", http.StatusUnauthorized},
+		{"other scheme", "Basic s3cret", http.StatusUnauthorized},
+		{"scheme prefix", "Bearers3cret", http.StatusUnauthorized},
+		{"wrong token", "Bearer guess", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(ErrorHandler(), AuthMiddleware(tokenValidator("s3cret")))
+			r.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+		})
+	}
+}
@@ -2,24 +2,135 @@ package middleware
 
 import (
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/dominikuswilly/nofu-be_product/internal/apperror"
 	"github.com/gin-gonic/gin"
 )
 
-func CORSMiddleware() gin.HandlerFunc {
+// ErrOriginNotAllowed is reported for preflight requests from origins outside the CORS policy
+var ErrOriginNotAllowed = apperror.Forbidden("cors_origin_not_allowed", "Origin not allowed")
+
+// CORSOptions is the CORS policy
+type CORSOptions struct {
+	// AllowedOrigins lists exact origins, patterns such as https://*.kopinofu.com matching
+	// any subdomain, or "*" for any origin
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	MaxAge           time.Duration
+}
+
+// originPattern matches subdomains of a wildcard origin such as https://*.kopinofu.com
+type originPattern struct {
+	prefix string // scheme, e.g. https://
+	suffix string // parent domain and port, e.g. .kopinofu.com
+}
+
+func (p originPattern) match(origin string) bool {
+	if len(origin) <= len(p.prefix)+len(p.suffix) ||
+		!strings.HasPrefix(origin, p.prefix) || !strings.HasSuffix(origin, p.suffix) {
+		return false
+	}
+	sub := origin[len(p.prefix) : len(origin)-len(p.suffix)]
+	for _, r := range sub {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' || r == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+// CORSMiddleware applies the CORS policy: it answers preflight requests and tells browsers
+// which origins may read responses and which response headers they may see
+func CORSMiddleware(opts CORSOptions) gin.HandlerFunc {
+	exact := map[string]bool{}
+	var patterns []originPattern
+	anyOrigin := false
+	for _, origin := range opts.AllowedOrigins {
+		origin = strings.ToLower(strings.TrimSuffix(origin, "/"))
+		switch {
+		case origin == "*":
+			anyOrigin = true
+		case strings.Contains(origin, "://*."):
+			scheme, host, _ := strings.Cut(origin, "://*")
+			patterns = append(patterns, originPattern{prefix: scheme + "://", suffix: host})
+		default:
+			exact[origin] = true
+		}
+	}
+	allowed := func(origin string) bool {
+		origin = strings.ToLower(origin)
+		if anyOrigin || exact[origin] {
+			return true
+		}
+		for _, p := range patterns {
+			if p.match(origin) {
+				return true
+			}
+		}
+		return false
+	}
+
+	methods := strings.Join(opts.AllowedMethods, ", ")
+	headers := strings.Join(opts.AllowedHeaders, ", ")
+	exposed := strings.Join(opts.ExposedHeaders, ", ")
+	maxAge := strconv.Itoa(int(opts.MaxAge.Seconds()))
+	// Credentialed responses must name the origin, so "*" only works without credentials
+	wildcard := anyOrigin && !opts.AllowCredentials
+
 	return func(c *gin.Context) {
 		origin := c.Request.Header.Get("Origin")
-		if origin == "http://kopinofu.com" {
-			c.Writer.Header().Set("Access-Control-Allow-Origin", origin)
-			c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
-			c.Writer.Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization")
+		h := c.Writer.Header()
+		// Responses differ per origin unless every origin gets the same "*", so caches must
+		// keep them apart even when the origin is rejected
+		if !wildcard {
+			h.Add("Vary", "Origin")
+		}
+		if origin == "" {
+			c.Next()
+			return
 		}
 
-		if c.Request.Method == "OPTIONS" {
+		preflight := c.Request.Method == http.MethodOptions && c.Request.Header.Get("Access-Control-Request-Method") != ""
+		if !allowed(origin) {
+			if preflight {
+				Abort(c, ErrOriginNotAllowed.WithDetail("origin "+origin+" may not call this API"))
+				return
+			}
+			// Serve the request but without CORS headers, so the browser hides the response
+			c.Next()
+			return
+		}
+
+		if wildcard {
+			h.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			h.Set("Access-Control-Allow-Origin", origin)
+		}
+		if opts.AllowCredentials {
+			h.Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		if preflight {
+			h.Add("Vary", "Access-Control-Request-Method")
+			h.Add("Vary", "Access-Control-Request-Headers")
+			h.Set("Access-Control-Allow-Methods", methods)
+			if headers != "" {
+				h.Set("Access-Control-Allow-Headers", headers)
+			}
+			h.Set("Access-Control-Max-Age", maxAge)
 			c.AbortWithStatus(http.StatusNoContent)
 			return
 		}
 
+		if exposed != "" {
+			h.Set("Access-Control-Expose-Headers", exposed)
+		}
 		c.Next()
 	}
 }
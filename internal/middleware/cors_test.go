@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCORSMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	opts := CORSOptions{
+		AllowedOrigins: []string{"https://kopinofu.com", "https://*.kopinofu.com", "http://localhost:3000"},
+		AllowedMethods: []string{"GET", "POST"},
+		AllowedHeaders: []string{"Authorization"},
+	}
+
+	tests := []struct {
+		name   string
+		origin string
+		want   bool
+	}{
+		{"exact origin", "https://kopinofu.com", true},
+		{"subdomain", "https://shop.kopinofu.com", true},
+		{"nested subdomain", "https://a.shop.kopinofu.com", true},
+		{"uppercase", "https://SHOP.KopiNofu.com", true},
+		{"exact origin with its port", "http://localhost:3000", true},
+		{"suffix look-alike", "https://evilkopinofu.com", false},
+		{"subdomain of a look-alike", "https://shop.evilkopinofu.com", false},
+		{"allowed domain as a subdomain", "https://kopinofu.com.evil.com", false},
+		{"path smuggling the suffix", "https://evil.com/.kopinofu.com", false},
+		{"bare wildcard parent", "https://.kopinofu.com", false},
+		{"other scheme", "http://kopinofu.com", false},
+		{"other scheme for a subdomain", "http://shop.kopinofu.com", false},
+		{"port on an exact origin", "https://kopinofu.com:8443", false},
+		{"port on a subdomain", "https://shop.kopinofu.com:8443", false},
+		{"other port", "http://localhost:3001", false},
+		{"no port", "http://localhost", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(ErrorHandler(), CORSMiddleware(opts))
+			r.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+			r.OPTIONS("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Origin", tt.origin)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want the request served either way", w.Code)
+			}
+			allowOrigin := w.Header().Get("Access-Control-Allow-Origin")
+			if tt.want && allowOrigin != tt.origin {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", allowOrigin, tt.origin)
+			}
+			if !tt.want && allowOrigin != "" {
+				t.Errorf("Access-Control-Allow-Origin = %q, want none", allowOrigin)
+			}
+
+			preflight := httptest.NewRequest(http.MethodOptions, "/", nil)
+			preflight.Header.Set("Origin", tt.origin)
+			preflight.Header.Set("Access-Control-Request-Method", http.MethodPost)
+			w = httptest.NewRecorder()
+			r.ServeHTTP(w, preflight)
+
+			wantStatus := http.StatusNoContent
+			if !tt.want {
+				wantStatus = http.StatusForbidden
+			}
+			if w.Code != wantStatus {
+				t.Fatalf("preflight status = %d, want %d", w.Code, wantStatus)
+			}
+			if !tt.want && w.Header().Get("Access-Control-Allow-Methods") != "" {
+				t.Errorf("a disallowed preflight got Access-Control-Allow-Methods %q", w.Header().Get("Access-Control-Allow-Methods"))
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/dominikuswilly/nofu-be_product/internal/apperror"
+	"github.com/dominikuswilly/nofu-be_product/internal/dto"
+	"github.com/dominikuswilly/nofu-be_product/internal/i18n"
+	"github.com/dominikuswilly/nofu-be_product/internal/logging"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// ProblemContentType is the media type of RFC 7807 error responses
+const ProblemContentType = "application/problem+json"
+
+// problemTypePrefix is prepended to the error code to form the problem type URI
+const problemTypePrefix = "urn:nofu:problem:"
+
+// ErrRouteNotFound is reported for requests that match no route
+var ErrRouteNotFound = apperror.NotFound("route_not_found", "Route not found")
+
+// kindStatus maps each error kind to its HTTP status
+var kindStatus = map[apperror.Kind]int{
+	apperror.KindValidation:   http.StatusBadRequest,
+	apperror.KindUnauthorized: http.StatusUnauthorized,
+	apperror.KindForbidden:    http.StatusForbidden,
+	apperror.KindNotFound:     http.StatusNotFound,
+	apperror.KindConflict:     http.StatusConflict,
+	apperror.KindRateLimited:  http.StatusTooManyRequests,
+	apperror.KindUnavailable:  http.StatusServiceUnavailable,
+	apperror.KindInternal:     http.StatusInternalServerError,
+}
+
+// ErrorHandler writes the last error that handlers added with c.Error as an RFC 7807
+// problem in the response envelope, translated to the language the client accepts.
+// Errors that are not an *apperror.Error are logged with the request logger and reported
+// as a generic internal error, so their details never reach the client.
+func ErrorHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+
+		err := c.Errors.Last().Err
+		appErr := apperror.As(err)
+		status, ok := kindStatus[appErr.Kind]
+		if !ok {
+			status = http.StatusInternalServerError
+		}
+		if status >= http.StatusInternalServerError {
+			logging.FromContext(c.Request.Context()).Error("Request failed",
+				zap.Error(err),
+				zap.String("code", appErr.Code),
+				zap.String("method", c.Request.Method),
+				zap.String("path", c.Request.URL.Path),
+			)
+		}
+
+		lang := i18n.FromAcceptLanguage(c.GetHeader("Accept-Language"))
+		c.Header("Content-Language", string(lang))
+		c.Header("Content-Type", ProblemContentType)
+		c.JSON(status, problemResponse(appErr, status, c.Request.URL.Path, lang))
+	}
+}
+
+// problemResponse builds the envelope of err with its title and field messages in lang
+func problemResponse(err *apperror.Error, status int, instance string, lang i18n.Lang) dto.Response {
+	title := i18n.Title(lang, err.Code, err.Title)
+	problem := &dto.Problem{
+		Type:       problemTypePrefix + err.Code,
+		Title:      title,
+		Status:     status,
+		Detail:     err.Detail,
+		Instance:   instance,
+		Code:       err.Code,
+		Extensions: err.Extensions,
+	}
+	for _, v := range err.Violations {
+		problem.Errors = append(problem.Errors, dto.FieldError{
+			Field:   v.Field,
+			Rule:    v.Rule,
+			Param:   v.Param,
+			Message: i18n.FieldMessage(lang, v.Field, v.Rule, v.Param, v.Length),
+		})
+	}
+
+	return dto.Response{
+		ResponseCode:    strconv.Itoa(status),
+		ResponseMessage: title,
+		Problem:         problem,
+	}
+}
+
+// Abort records err for ErrorHandler to write and stops the remaining handlers
+func Abort(c *gin.Context, err error) {
+	_ = c.Error(err)
+	c.Abort()
+}
+
+// Recovery turns panics into internal errors for ErrorHandler to write, logging the stack
+// with the request logger. It must run after ErrorHandler.
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(io.Discard, func(c *gin.Context, recovered any) {
+		logging.FromContext(c.Request.Context()).Error("Panic recovered",
+			zap.Any("panic", recovered),
+			zap.Stack("stack"),
+		)
+		Abort(c, apperror.ErrInternal)
+	})
+}
@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"github.com/dominikuswilly/nofu-be_product/internal/metrics"
+	"github.com/gin-gonic/gin"
+)
+
+// Metrics records the rate, errors and duration of requests per route. Requests that match
+// no route share the "unmatched" route so that scanners cannot inflate the label set. It
+// must run before ErrorHandler to see the final status.
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		done := metrics.RequestStarted()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		done(c.Request.Method, route, c.Writer.Status())
+	}
+}
@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"github.com/dominikuswilly/nofu-be_product/internal/apperror"
+	"github.com/dominikuswilly/nofu-be_product/internal/auth"
+	"github.com/gin-gonic/gin"
+)
+
+// ErrMissingPermission is reported when the principal lacks the permission a route requires
+var ErrMissingPermission = apperror.Forbidden("missing_permission", "Missing permission")
+
+// RequirePermission rejects requests whose principal is not granted perm by policy.
+// It must run after AuthMiddleware.
+func RequirePermission(policy *auth.Policy, perm auth.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal := auth.PrincipalFromContext(c.Request.Context())
+		if principal == nil {
+			Abort(c, ErrUnauthorized)
+			return
+		}
+
+		if !policy.Allows(principal, perm) {
+			Abort(c, ErrMissingPermission.
+				WithDetail("Missing permission "+string(perm)).
+				WithExtension("missingPermission", perm))
+			return
+		}
+
+		c.Next()
+	}
+}
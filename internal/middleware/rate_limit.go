@@ -0,0 +1,152 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dominikuswilly/nofu-be_product/internal/apperror"
+	"github.com/dominikuswilly/nofu-be_product/internal/auth"
+	"github.com/dominikuswilly/nofu-be_product/internal/logging"
+	"github.com/dominikuswilly/nofu-be_product/internal/metrics"
+	"github.com/dominikuswilly/nofu-be_product/internal/ratelimit"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// ErrRateLimited is reported when a client exceeds its request rate
+var ErrRateLimited = apperror.RateLimited("rate_limited", "Too many requests")
+
+// Route groups with a rate limit of their own
+const (
+	// RateGroupAuth limits each client IP before its token is validated, so that tokens
+	// cannot be brute-forced through to the auth service
+	RateGroupAuth = "auth"
+	// RateGroupRead limits each user's GET requests
+	RateGroupRead = "read"
+	// RateGroupWrite limits each user's other requests
+	RateGroupWrite = "write"
+	// RateGroupCatalog limits each client of the public catalog
+	RateGroupCatalog = "catalog"
+)
+
+// APIKeyHeader identifies clients such as storefront integrations
+const APIKeyHeader = "X-API-Key"
+
+// RateLimiter applies the limits of the route groups to requests. A nil *RateLimiter
+// limits nothing.
+type RateLimiter struct {
+	store  ratelimit.Store
+	limits map[string]ratelimit.Limit
+	// apiKeys holds the hashes of the registered API keys
+	apiKeys map[string]bool
+}
+
+// NewRateLimiter returns a limiter taking tokens from store. Groups without a limit are
+// not limited. Clients sending one of apiKeys get limits of their own; any other key is
+// ignored, so that made-up keys cannot each claim a fresh bucket.
+func NewRateLimiter(store ratelimit.Store, limits map[string]ratelimit.Limit, apiKeys []string) *RateLimiter {
+	hashes := make(map[string]bool, len(apiKeys))
+	for _, key := range apiKeys {
+		hashes[hashAPIKey(key)] = true
+	}
+	return &RateLimiter{store: store, limits: limits, apiKeys: hashes}
+}
+
+// PerIP limits group per client IP
+func (l *RateLimiter) PerIP(group string) gin.HandlerFunc {
+	return l.handler(func(c *gin.Context) (string, string) {
+		return group, "ip:" + c.ClientIP()
+	})
+}
+
+// PerClient limits group per registered API key, or per client IP for requests without one
+func (l *RateLimiter) PerClient(group string) gin.HandlerFunc {
+	return l.handler(func(c *gin.Context) (string, string) {
+		return group, l.clientKey(c)
+	})
+}
+
+// PerUser limits the read and write groups per authenticated user, falling back to the
+// registered API key or client IP. It must run after AuthMiddleware.
+func (l *RateLimiter) PerUser() gin.HandlerFunc {
+	return l.handler(func(c *gin.Context) (string, string) {
+		group := RateGroupWrite
+		if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+			group = RateGroupRead
+		}
+		if p := auth.PrincipalFromContext(c.Request.Context()); p != nil && p.UserID != "" {
+			return group, "user:" + p.TenantID + ":" + p.UserID
+		}
+		return group, l.clientKey(c)
+	})
+}
+
+// clientKey identifies the client by its API key when the key is registered, or by IP
+func (l *RateLimiter) clientKey(c *gin.Context) string {
+	if key := c.GetHeader(APIKeyHeader); key != "" {
+		if hash := hashAPIKey(key); l.apiKeys[hash] {
+			return "key:" + hash
+		}
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// hashAPIKey hashes key so that keys are not stored in the rate limit store
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:16])
+}
+
+func (l *RateLimiter) handler(keyOf func(c *gin.Context) (group, key string)) gin.HandlerFunc {
+	if l == nil {
+		return func(c *gin.Context) { c.Next() }
+	}
+
+	return func(c *gin.Context) {
+		group, key := keyOf(c)
+		limit, ok := l.limits[group]
+		if !ok {
+			c.Next()
+			return
+		}
+
+		res, err := l.store.Take(c.Request.Context(), group+":"+key, limit)
+		if err != nil {
+			// Fail open: an unreachable store must not take the API down with it
+			metrics.ObserveRateLimit(group, metrics.RateLimitError)
+			logging.FromContext(c.Request.Context()).Warn("Rate limit store unavailable, request not limited",
+				zap.String("group", group), zap.Error(err))
+			c.Next()
+			return
+		}
+
+		setRateLimitHeaders(c.Writer.Header(), limit, res)
+		if !res.Allowed {
+			metrics.ObserveRateLimit(group, metrics.RateLimitLimited)
+			c.Header("Retry-After", strconv.Itoa(ceilSeconds(res.RetryAfter)))
+			Abort(c, ErrRateLimited)
+			return
+		}
+		metrics.ObserveRateLimit(group, metrics.RateLimitAllowed)
+		c.Next()
+	}
+}
+
+// setRateLimitHeaders sets the RateLimit-* headers, unless an earlier limit of the request
+// has fewer requests remaining
+func setRateLimitHeaders(h http.Header, limit ratelimit.Limit, res ratelimit.Result) {
+	if prev, err := strconv.Atoi(h.Get("RateLimit-Remaining")); err == nil && prev < res.Remaining {
+		return
+	}
+	h.Set("RateLimit-Limit", strconv.Itoa(limit.Burst))
+	h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+	h.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(res.Reset)))
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
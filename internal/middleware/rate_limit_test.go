@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dominikuswilly/nofu-be_product/internal/ratelimit"
+	"github.com/gin-gonic/gin"
+)
+
+func TestRateLimiterPerClient(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	type request struct {
+		remoteAddr   string
+		forwardedFor string
+		apiKey       string
+		wantLimited  bool
+	}
+	tests := []struct {
+		name           string
+		trustedProxies []string
+		requests       []request
+	}{
+		{
+			name: "spoofed X-Forwarded-For shares the connection's bucket",
+			requests: []request{
+				{remoteAddr: "203.0.113.7:4000", forwardedFor: "198.51.100.1"},
+				{remoteAddr: "203.0.113.7:4001", forwardedFor: "198.51.100.2", wantLimited: true},
+			},
+		},
+		{
+			name:           "X-Forwarded-For of a trusted proxy names the client",
+			trustedProxies: []string{"10.0.0.0/8"},
+			requests: []request{
+				{remoteAddr: "10.0.0.1:4000", forwardedFor: "198.51.100.1"},
+				{remoteAddr: "10.0.0.1:4001", forwardedFor: "198.51.100.2"},
+				{remoteAddr: "10.0.0.2:4000", forwardedFor: "198.51.100.1", wantLimited: true},
+			},
+		},
+		{
+			name: "unregistered API keys share the IP's bucket",
+			requests: []request{
+				{remoteAddr: "203.0.113.7:4000", apiKey: "made-up-1"},
+				{remoteAddr: "203.0.113.7:4001", apiKey: "made-up-2", wantLimited: true},
+			},
+		},
+		{
+			name: "registered API keys have buckets of their own",
+			requests: []request{
+				{remoteAddr: "203.0.113.7:4000"},
+				{remoteAddr: "203.0.113.7:4001", apiKey: "storefront"},
+				{remoteAddr: "198.51.100.9:4000", apiKey: "storefront", wantLimited: true},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := NewRateLimiter(ratelimit.NewMemoryStore(), map[string]ratelimit.Limit{
+				RateGroupCatalog: {Rate: 0.001, Burst: 1},
+			}, []string{"storefront"})
+
+			r := gin.New()
+			if err := r.SetTrustedProxies(tt.trustedProxies); err != nil {
+				t.Fatal(err)
+			}
+			r.Use(ErrorHandler(), limiter.PerClient(RateGroupCatalog))
+			r.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			for i, req := range tt.requests {
+				httpReq := httptest.NewRequest(http.MethodGet, "/", nil)
+				httpReq.RemoteAddr = req.remoteAddr
+				if req.forwardedFor != "" {
+					httpReq.Header.Set("X-Forwarded-For", req.forwardedFor)
+				}
+				if req.apiKey != "" {
+					httpReq.Header.Set(APIKeyHeader, req.apiKey)
+				}
+				w := httptest.NewRecorder()
+				r.ServeHTTP(w, httpReq)
+
+				want := http.StatusOK
+				if req.wantLimited {
+					want = http.StatusTooManyRequests
+				}
+				if w.Code != want {
+					t.Errorf("request %d: status = %d, want %d", i+1, w.Code, want)
+				}
+			}
+		})
+	}
+}
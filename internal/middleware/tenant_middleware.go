@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/dominikuswilly/nofu-be_product/internal/apperror"
+	"github.com/dominikuswilly/nofu-be_product/internal/auth"
+	"github.com/dominikuswilly/nofu-be_product/internal/tenant"
+	"github.com/gin-gonic/gin"
+)
+
+// TenantKey is the gin context key under which the resolved tenant ID is stored
+const TenantKey = "tenant"
+
+var (
+	// ErrTenantMismatch is reported when the tenant header differs from the tenant of the token
+	ErrTenantMismatch = apperror.Forbidden("tenant_mismatch", "Token is not valid for the tenant")
+	// ErrTenantRequired is reported when no tenant can be resolved for the request
+	ErrTenantRequired = apperror.Validation("tenant_required", "Tenant is required")
+	// ErrInvalidTenant is reported for a malformed tenant ID
+	ErrInvalidTenant = apperror.Validation("invalid_tenant", "Invalid tenant ID")
+)
+
+// TenantMiddleware resolves the tenant of the request and scopes the request context to it.
+// An authenticated principal bound to a tenant always acts on it. Other principals act on
+// defaultTenant, and may only pick another tenant with the X-Tenant-ID header when policy
+// grants them auth.PermTenantAny. Anonymous requests, e.g. to the public catalog, use the
+// header, then defaultTenant; policy may be nil on such routes. When used on authenticated
+// routes it must run after AuthMiddleware.
+func TenantMiddleware(defaultTenant string, policy *auth.Policy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := strings.TrimSpace(c.GetHeader(tenant.Header))
+
+		tenantID := defaultTenant
+		switch p := auth.PrincipalFromContext(c.Request.Context()); {
+		case p != nil && p.TenantID != "":
+			if header != "" && header != p.TenantID {
+				Abort(c, ErrTenantMismatch.WithDetail("Token is not valid for tenant "+header))
+				return
+			}
+			tenantID = p.TenantID
+		case p != nil:
+			if header != "" && header != defaultTenant {
+				if policy == nil || !policy.Allows(p, auth.PermTenantAny) {
+					Abort(c, ErrTenantMismatch.WithDetail("Token is not bound to tenant "+header+" and lacks "+string(auth.PermTenantAny)))
+					return
+				}
+				tenantID = header
+			}
+		case header != "":
+			tenantID = header
+		}
+
+		if tenantID == "" {
+			Abort(c, ErrTenantRequired.WithDetail(tenant.Header+" header is required"))
+			return
+		}
+		if !tenant.ValidID(tenantID) {
+			Abort(c, ErrInvalidTenant)
+			return
+		}
+
+		c.Set(TenantKey, tenantID)
+		c.Request = c.Request.WithContext(tenant.WithID(c.Request.Context(), tenantID))
+		c.Next()
+	}
+}
@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dominikuswilly/nofu-be_product/internal/auth"
+	"github.com/dominikuswilly/nofu-be_product/internal/tenant"
+	"github.com/gin-gonic/gin"
+)
+
+func TestTenantMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	policy := auth.NewPolicy(map[string][]auth.Permission{
+		"admin":    {auth.PermAll},
+		"operator": {auth.PermProductRead, auth.PermTenantAny},
+	})
+
+	tests := []struct {
+		name       string
+		principal  *auth.Principal
+		header     string
+		wantStatus int
+		wantTenant string
+	}{
+		{"anonymous uses the header", nil, "brand-b", http.StatusOK, "brand-b"},
+		{"anonymous falls back to the default", nil, "", http.StatusOK, "default"},
+		{"bound principal", &auth.Principal{TenantID: "brand-a"}, "", http.StatusOK, "brand-a"},
+		{"bound principal with its own tenant", &auth.Principal{TenantID: "brand-a"}, "brand-a", http.StatusOK, "brand-a"},
+		{"bound principal with another tenant", &auth.Principal{TenantID: "brand-a"}, "brand-b", http.StatusForbidden, ""},
+		{"unbound principal uses the default", &auth.Principal{Roles: []string{"admin"}}, "", http.StatusOK, "default"},
+		{"unbound principal naming the default", &auth.Principal{Roles: []string{"barista"}}, "default", http.StatusOK, "default"},
+		{"unbound principal without tenant:any", &auth.Principal{Roles: []string{"barista"}}, "brand-b", http.StatusForbidden, ""},
+		{"unbound principal with * but not tenant:any", &auth.Principal{Roles: []string{"admin"}}, "brand-b", http.StatusForbidden, ""},
+		{"unbound principal with tenant:any", &auth.Principal{Roles: []string{"operator"}}, "brand-b", http.StatusOK, "brand-b"},
+		{"invalid tenant", nil, "not a tenant!", http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(ErrorHandler(), func(c *gin.Context) {
+				if tt.principal != nil {
+					c.Request = c.Request.WithContext(auth.WithPrincipal(c.Request.Context(), tt.principal))
+				}
+			}, TenantMiddleware("default", policy))
+			r.GET("/", func(c *gin.Context) {
+				id, _ := tenant.FromContext(c.Request.Context())
+				c.String(http.StatusOK, id)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set(tenant.Header, tt.header)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.wantStatus == http.StatusOK && w.Body.String() != tt.wantTenant {
+				t.Errorf("tenant = %q, want %q", w.Body, tt.wantTenant)
+			}
+		})
+	}
+}
@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+)
+
+// Tracing continues the W3C trace of the caller, or starts a new one, in a server span
+// named after the route. Metrics scrapes and probes are not traced. It must run before ErrorHandler
+// to see the final status.
+func Tracing(service string) gin.HandlerFunc {
+	return otelgin.Middleware(service, otelgin.WithFilter(func(r *http.Request) bool {
+		return !untracedPaths[r.URL.Path]
+	}))
+}
+
+// untracedPaths are polled by infrastructure and would drown out the traces of real requests
+var untracedPaths = map[string]bool{
+	"/livez":  true,
+	"/readyz": true,
+}
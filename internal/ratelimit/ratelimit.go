@@ -0,0 +1,50 @@
+// Package ratelimit implements token bucket rate limiting over a pluggable store: in
+// memory for a single instance, or Redis (or a compatible server) shared by a cluster.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Limit is a token bucket: it refills at Rate tokens per second up to Burst tokens, and
+// every request takes one token
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Result is the outcome of taking a token
+type Result struct {
+	Allowed bool
+	// Remaining is the number of whole tokens left in the bucket
+	Remaining int
+	// RetryAfter is how long until the next token, zero when Allowed
+	RetryAfter time.Duration
+	// Reset is how long until the bucket is full again
+	Reset time.Duration
+}
+
+// Store keeps the buckets
+type Store interface {
+	// Take takes a token from the bucket of key, which is full when first used
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// result describes the bucket left with tokens after taking a token, if one was available
+func result(limit Limit, tokens float64, allowed bool) Result {
+	r := Result{
+		Allowed:   allowed,
+		Remaining: int(math.Floor(tokens)),
+		Reset:     seconds((float64(limit.Burst) - tokens) / limit.Rate),
+	}
+	if !allowed {
+		r.RetryAfter = seconds((1 - tokens) / limit.Rate)
+	}
+	return r
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
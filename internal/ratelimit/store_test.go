@@ -0,0 +1,98 @@
+package ratelimit_test
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/dominikuswilly/nofu-be_product/internal/ratelimit"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, ratelimit.NewMemoryStore())
+}
+
+// TestRedisStore runs the store tests against the server in TEST_REDIS_URL, e.g.
+// "redis://localhost:6379/15". Any Redis-compatible server will do, such as Valkey.
+func TestRedisStore(t *testing.T) {
+	url := os.Getenv("TEST_REDIS_URL")
+	if url == "" {
+		t.Skip("TEST_REDIS_URL is not set")
+	}
+
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		t.Fatalf("parse TEST_REDIS_URL: %v", err)
+	}
+	client := redis.NewClient(opts)
+	t.Cleanup(func() { client.Close() })
+	// Every run uses keys of its own, so the server does not need to be empty
+	prefix := "ratelimit_test:" + strconv.FormatInt(time.Now().UnixNano(), 36) + ":"
+	testStore(t, ratelimit.NewRedisStore(client, prefix))
+}
+
+func testStore(t *testing.T, store ratelimit.Store) {
+	ctx := context.Background()
+	limit := ratelimit.Limit{Rate: 20, Burst: 3}
+
+	t.Run("burst then limited", func(t *testing.T) {
+		for i := 0; i < limit.Burst; i++ {
+			res, err := store.Take(ctx, "burst", limit)
+			if err != nil {
+				t.Fatalf("take %d: %v", i, err)
+			}
+			if !res.Allowed || res.Remaining != limit.Burst-1-i {
+				t.Fatalf("take %d: got allowed %v remaining %d, want allowed with %d remaining", i, res.Allowed, res.Remaining, limit.Burst-1-i)
+			}
+		}
+
+		res, err := store.Take(ctx, "burst", limit)
+		if err != nil {
+			t.Fatalf("take: %v", err)
+		}
+		if res.Allowed || res.Remaining != 0 {
+			t.Fatalf("got allowed %v remaining %d, want limited", res.Allowed, res.Remaining)
+		}
+		if res.RetryAfter <= 0 || res.RetryAfter > time.Second/20 {
+			t.Errorf("retry after %s, want within one token interval", res.RetryAfter)
+		}
+		if res.Reset < res.RetryAfter || res.Reset > 3*time.Second/20 {
+			t.Errorf("reset %s, want time to refill %d tokens", res.Reset, limit.Burst)
+		}
+	})
+
+	t.Run("keys are independent", func(t *testing.T) {
+		for i := 0; i < limit.Burst; i++ {
+			if _, err := store.Take(ctx, "drained", limit); err != nil {
+				t.Fatalf("take: %v", err)
+			}
+		}
+		res, err := store.Take(ctx, "other", limit)
+		if err != nil {
+			t.Fatalf("take: %v", err)
+		}
+		if !res.Allowed || res.Remaining != limit.Burst-1 {
+			t.Fatalf("got allowed %v remaining %d, want a full bucket", res.Allowed, res.Remaining)
+		}
+	})
+
+	t.Run("refills over time", func(t *testing.T) {
+		for i := 0; i < limit.Burst; i++ {
+			if _, err := store.Take(ctx, "refill", limit); err != nil {
+				t.Fatalf("take: %v", err)
+			}
+		}
+		time.Sleep(2 * time.Second / 20)
+
+		res, err := store.Take(ctx, "refill", limit)
+		if err != nil {
+			t.Fatalf("take: %v", err)
+		}
+		if !res.Allowed {
+			t.Fatalf("limited after waiting for two tokens")
+		}
+	})
+}
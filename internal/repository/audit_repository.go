@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/dominikuswilly/nofu-be_product/internal/entity"
+)
+
+// AuditRepository defines the interface for product audit log access
+type AuditRepository interface {
+	Create(ctx context.Context, entry *entity.AuditEntry) error
+	ListByProduct(ctx context.Context, productID string) ([]*entity.AuditEntry, error)
+}
+
+// sqlAuditRepository implements AuditRepository for PostgreSQL and SQLite
+type sqlAuditRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresAuditRepository creates an AuditRepository backed by PostgreSQL
+func NewPostgresAuditRepository(db *sql.DB) AuditRepository {
+	return &sqlAuditRepository{db: db}
+}
+
+func (r *sqlAuditRepository) Create(ctx context.Context, entry *entity.AuditEntry) error {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	entry.TenantID = tenantID
+
+	query := `
+		INSERT INTO product_audit (c_id, c_tenant_id, c_product_id, c_action, c_actor_id, c_actor_nm, j_changes, ts_created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+	changes, err := encodeJSON(entry.Changes, len(entry.Changes) == 0)
+	if err != nil {
+		return err
+	}
+
+	_, err = conn(ctx, r.db).ExecContext(ctx, query,
+		entry.ID,
+		entry.TenantID,
+		entry.ProductID,
+		entry.Action,
+		entry.ActorID,
+		entry.ActorName,
+		changes,
+		entry.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create audit entry: %w", err)
+	}
+	return nil
+}
+
+func (r *sqlAuditRepository) ListByProduct(ctx context.Context, productID string) ([]*entity.AuditEntry, error) {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT c_id, c_tenant_id, c_product_id, c_action, c_actor_id, c_actor_nm, j_changes, ts_created_at
+		FROM product_audit
+		WHERE c_product_id = $1 AND c_tenant_id = $2
+		ORDER BY ts_created_at DESC, c_id DESC
+	`
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, productID, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit entries: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []*entity.AuditEntry
+	for rows.Next() {
+		entry := &entity.AuditEntry{}
+		var changes []byte
+		if err := rows.Scan(
+			&entry.ID,
+			&entry.TenantID,
+			&entry.ProductID,
+			&entry.Action,
+			&entry.ActorID,
+			&entry.ActorName,
+			&changes,
+			&entry.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan audit entry: %w", err)
+		}
+		if err := decodeJSON(changes, &entry.Changes); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
@@ -0,0 +1,189 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/dominikuswilly/nofu-be_product/internal/apperror"
+	"github.com/dominikuswilly/nofu-be_product/internal/entity"
+)
+
+var (
+	// ErrChangeRequestNotFound is returned when the tenant has no change request with the given ID
+	ErrChangeRequestNotFound = apperror.NotFound("change_request_not_found", "Change request not found")
+	// ErrChangeRequestClosed is returned when reviewing a change request that is no longer pending
+	ErrChangeRequestClosed = apperror.Conflict("change_request_closed", "Change request is no longer pending")
+)
+
+// ChangeRequestRepository defines the interface for product change request data access
+type ChangeRequestRepository interface {
+	Create(ctx context.Context, cr *entity.ChangeRequest) error
+	GetByID(ctx context.Context, id string) (*entity.ChangeRequest, error)
+	List(ctx context.Context, filter ChangeRequestFilter) ([]*entity.ChangeRequest, error)
+	UpdateStatus(ctx context.Context, cr *entity.ChangeRequest, from entity.ChangeRequestStatus) error
+}
+
+// ChangeRequestFilter narrows down the change requests returned by List. Empty fields match all.
+type ChangeRequestFilter struct {
+	Status    entity.ChangeRequestStatus
+	ProductID string
+}
+
+// sqlChangeRequestRepository implements ChangeRequestRepository for PostgreSQL and SQLite
+type sqlChangeRequestRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresChangeRequestRepository creates a ChangeRequestRepository backed by PostgreSQL
+func NewPostgresChangeRequestRepository(db *sql.DB) ChangeRequestRepository {
+	return &sqlChangeRequestRepository{db: db}
+}
+
+func (r *sqlChangeRequestRepository) Create(ctx context.Context, cr *entity.ChangeRequest) error {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	cr.TenantID = tenantID
+
+	query := `
+		INSERT INTO product_change_request (c_id, c_tenant_id, c_product_id, c_status, j_patch, j_changes, c_requested_by, c_requested_by_nm, ts_created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+	patch, err := encodeJSON(cr.Patch, false)
+	if err != nil {
+		return err
+	}
+	changes, err := encodeJSON(cr.Changes, len(cr.Changes) == 0)
+	if err != nil {
+		return err
+	}
+
+	_, err = conn(ctx, r.db).ExecContext(ctx, query,
+		cr.ID,
+		cr.TenantID,
+		cr.ProductID,
+		cr.Status,
+		patch,
+		changes,
+		cr.RequestedBy,
+		cr.RequestedByName,
+		cr.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create change request: %w", err)
+	}
+	return nil
+}
+
+func (r *sqlChangeRequestRepository) GetByID(ctx context.Context, id string) (*entity.ChangeRequest, error) {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT c_id, c_tenant_id, c_product_id, c_status, j_patch, j_changes, c_requested_by, c_requested_by_nm, c_reviewed_by, c_review_comment, ts_created_at, ts_reviewed_at
+		FROM product_change_request
+		WHERE c_id = $1 AND c_tenant_id = $2
+	`
+	cr, err := scanChangeRequest(conn(ctx, r.db).QueryRowContext(ctx, query, id, tenantID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get change request by id: %w", err)
+	}
+	return cr, nil
+}
+
+func (r *sqlChangeRequestRepository) List(ctx context.Context, filter ChangeRequestFilter) ([]*entity.ChangeRequest, error) {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT c_id, c_tenant_id, c_product_id, c_status, j_patch, j_changes, c_requested_by, c_requested_by_nm, c_reviewed_by, c_review_comment, ts_created_at, ts_reviewed_at
+		FROM product_change_request
+		WHERE c_tenant_id = $1 AND ($2 = '' OR c_status = $2) AND ($3 = '' OR c_product_id = $3)
+		ORDER BY ts_created_at DESC, c_id DESC
+	`
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, tenantID, filter.Status, filter.ProductID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list change requests: %w", err)
+	}
+	defer rows.Close()
+
+	var requests []*entity.ChangeRequest
+	for rows.Next() {
+		cr, err := scanChangeRequest(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan change request: %w", err)
+		}
+		requests = append(requests, cr)
+	}
+	return requests, rows.Err()
+}
+
+// UpdateStatus stores the review outcome of cr, as long as its status is still from.
+// This keeps two reviewers from deciding on the same request.
+func (r *sqlChangeRequestRepository) UpdateStatus(ctx context.Context, cr *entity.ChangeRequest, from entity.ChangeRequestStatus) error {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE product_change_request
+		SET c_status = $1, c_reviewed_by = $2, c_review_comment = $3, ts_reviewed_at = $4
+		WHERE c_id = $5 AND c_tenant_id = $6 AND c_status = $7
+	`
+	res, err := conn(ctx, r.db).ExecContext(ctx, query,
+		cr.Status,
+		cr.ReviewedBy,
+		cr.ReviewComment,
+		cr.ReviewedAt,
+		cr.ID,
+		tenantID,
+		from,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update change request: %w", err)
+	}
+
+	rowsAffected, _ := res.RowsAffected()
+	if rowsAffected == 0 {
+		return ErrChangeRequestClosed
+	}
+	return nil
+}
+
+func scanChangeRequest(row rowScanner) (*entity.ChangeRequest, error) {
+	cr := &entity.ChangeRequest{}
+	var patch, changes []byte
+	if err := row.Scan(
+		&cr.ID,
+		&cr.TenantID,
+		&cr.ProductID,
+		&cr.Status,
+		&patch,
+		&changes,
+		&cr.RequestedBy,
+		&cr.RequestedByName,
+		&cr.ReviewedBy,
+		&cr.ReviewComment,
+		&cr.CreatedAt,
+		&cr.ReviewedAt,
+	); err != nil {
+		return nil, err
+	}
+	if err := decodeJSON(patch, &cr.Patch); err != nil {
+		return nil, err
+	}
+	if err := decodeJSON(changes, &cr.Changes); err != nil {
+		return nil, err
+	}
+	return cr, nil
+}
@@ -0,0 +1,274 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/dominikuswilly/nofu-be_product/internal/entity"
+)
+
+// QueryHook is called before every repository call with the repository and method name.
+// It may return a derived context for the call and returns a function that is called
+// with the outcome once the call is done.
+type QueryHook func(ctx context.Context, repo, method string) (context.Context, func(err error))
+
+// QueryTimeout returns a hook that cancels repository calls taking longer than timeout, so a
+// stuck query cannot hold a connection and the request forever
+func QueryTimeout(timeout time.Duration) QueryHook {
+	return func(ctx context.Context, _, _ string) (context.Context, func(err error)) {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		return ctx, func(error) { cancel() }
+	}
+}
+
+// NewInstrumentedProductRepository calls hook around every call to next
+func NewInstrumentedProductRepository(next ProductRepository, hook QueryHook) ProductRepository {
+	return &instrumentedProductRepository{next: next, hook: hook}
+}
+
+type instrumentedProductRepository struct {
+	next ProductRepository
+	hook QueryHook
+}
+
+func (r *instrumentedProductRepository) Create(ctx context.Context, product *entity.Product) (err error) {
+	ctx, done := r.hook(ctx, "product", "Create")
+	defer func() { done(err) }()
+	return r.next.Create(ctx, product)
+}
+
+func (r *instrumentedProductRepository) GetByID(ctx context.Context, id string) (_ *entity.Product, err error) {
+	ctx, done := r.hook(ctx, "product", "GetByID")
+	defer func() { done(err) }()
+	return r.next.GetByID(ctx, id)
+}
+
+func (r *instrumentedProductRepository) GetByIDForUpdate(ctx context.Context, id string) (_ *entity.Product, err error) {
+	ctx, done := r.hook(ctx, "product", "GetByIDForUpdate")
+	defer func() { done(err) }()
+	return r.next.GetByIDForUpdate(ctx, id)
+}
+
+func (r *instrumentedProductRepository) GetAll(ctx context.Context, filter ProductFilter) (_ []*entity.Product, err error) {
+	ctx, done := r.hook(ctx, "product", "GetAll")
+	defer func() { done(err) }()
+	return r.next.GetAll(ctx, filter)
+}
+
+func (r *instrumentedProductRepository) Update(ctx context.Context, product *entity.Product) (err error) {
+	ctx, done := r.hook(ctx, "product", "Update")
+	defer func() { done(err) }()
+	return r.next.Update(ctx, product)
+}
+
+func (r *instrumentedProductRepository) ReplaceImage(ctx context.Context, product *entity.Product) (err error) {
+	ctx, done := r.hook(ctx, "product", "ReplaceImage")
+	defer func() { done(err) }()
+	return r.next.ReplaceImage(ctx, product)
+}
+
+func (r *instrumentedProductRepository) UpdateImages(ctx context.Context, id, sourceUrl string, images map[string]entity.ProductImage) (err error) {
+	ctx, done := r.hook(ctx, "product", "UpdateImages")
+	defer func() { done(err) }()
+	return r.next.UpdateImages(ctx, id, sourceUrl, images)
+}
+
+func (r *instrumentedProductRepository) AdjustStock(ctx context.Context, id string, delta int64, updatedBy string) (_ int64, err error) {
+	ctx, done := r.hook(ctx, "product", "AdjustStock")
+	defer func() { done(err) }()
+	return r.next.AdjustStock(ctx, id, delta, updatedBy)
+}
+
+func (r *instrumentedProductRepository) UpdateStatus(ctx context.Context, product *entity.Product, from entity.ProductStatus) (err error) {
+	ctx, done := r.hook(ctx, "product", "UpdateStatus")
+	defer func() { done(err) }()
+	return r.next.UpdateStatus(ctx, product, from)
+}
+
+func (r *instrumentedProductRepository) Delete(ctx context.Context, id string) (err error) {
+	ctx, done := r.hook(ctx, "product", "Delete")
+	defer func() { done(err) }()
+	return r.next.Delete(ctx, id)
+}
+
+func (r *instrumentedProductRepository) CountByTenant(ctx context.Context, lowStock int64) (_ []ProductCounts, err error) {
+	ctx, done := r.hook(ctx, "product", "CountByTenant")
+	defer func() { done(err) }()
+	return r.next.CountByTenant(ctx, lowStock)
+}
+
+// NewInstrumentedAuditRepository calls hook around every call to next
+func NewInstrumentedAuditRepository(next AuditRepository, hook QueryHook) AuditRepository {
+	return &instrumentedAuditRepository{next: next, hook: hook}
+}
+
+type instrumentedAuditRepository struct {
+	next AuditRepository
+	hook QueryHook
+}
+
+func (r *instrumentedAuditRepository) Create(ctx context.Context, entry *entity.AuditEntry) (err error) {
+	ctx, done := r.hook(ctx, "audit", "Create")
+	defer func() { done(err) }()
+	return r.next.Create(ctx, entry)
+}
+
+func (r *instrumentedAuditRepository) ListByProduct(ctx context.Context, productID string) (_ []*entity.AuditEntry, err error) {
+	ctx, done := r.hook(ctx, "audit", "ListByProduct")
+	defer func() { done(err) }()
+	return r.next.ListByProduct(ctx, productID)
+}
+
+// NewInstrumentedProductVersionRepository calls hook around every call to next
+func NewInstrumentedProductVersionRepository(next ProductVersionRepository, hook QueryHook) ProductVersionRepository {
+	return &instrumentedProductVersionRepository{next: next, hook: hook}
+}
+
+type instrumentedProductVersionRepository struct {
+	next ProductVersionRepository
+	hook QueryHook
+}
+
+func (r *instrumentedProductVersionRepository) Create(ctx context.Context, version *entity.ProductVersion) (err error) {
+	ctx, done := r.hook(ctx, "product_version", "Create")
+	defer func() { done(err) }()
+	return r.next.Create(ctx, version)
+}
+
+func (r *instrumentedProductVersionRepository) ListByProduct(ctx context.Context, productID string) (_ []*entity.ProductVersion, err error) {
+	ctx, done := r.hook(ctx, "product_version", "ListByProduct")
+	defer func() { done(err) }()
+	return r.next.ListByProduct(ctx, productID)
+}
+
+func (r *instrumentedProductVersionRepository) Get(ctx context.Context, productID string, version int) (_ *entity.ProductVersion, err error) {
+	ctx, done := r.hook(ctx, "product_version", "Get")
+	defer func() { done(err) }()
+	return r.next.Get(ctx, productID, version)
+}
+
+// NewInstrumentedOutletRepository calls hook around every call to next
+func NewInstrumentedOutletRepository(next OutletRepository, hook QueryHook) OutletRepository {
+	return &instrumentedOutletRepository{next: next, hook: hook}
+}
+
+type instrumentedOutletRepository struct {
+	next OutletRepository
+	hook QueryHook
+}
+
+func (r *instrumentedOutletRepository) Create(ctx context.Context, outlet *entity.Outlet) (err error) {
+	ctx, done := r.hook(ctx, "outlet", "Create")
+	defer func() { done(err) }()
+	return r.next.Create(ctx, outlet)
+}
+
+func (r *instrumentedOutletRepository) GetByID(ctx context.Context, id string) (_ *entity.Outlet, err error) {
+	ctx, done := r.hook(ctx, "outlet", "GetByID")
+	defer func() { done(err) }()
+	return r.next.GetByID(ctx, id)
+}
+
+func (r *instrumentedOutletRepository) GetAll(ctx context.Context) (_ []*entity.Outlet, err error) {
+	ctx, done := r.hook(ctx, "outlet", "GetAll")
+	defer func() { done(err) }()
+	return r.next.GetAll(ctx)
+}
+
+func (r *instrumentedOutletRepository) Update(ctx context.Context, outlet *entity.Outlet) (err error) {
+	ctx, done := r.hook(ctx, "outlet", "Update")
+	defer func() { done(err) }()
+	return r.next.Update(ctx, outlet)
+}
+
+func (r *instrumentedOutletRepository) Delete(ctx context.Context, id string) (err error) {
+	ctx, done := r.hook(ctx, "outlet", "Delete")
+	defer func() { done(err) }()
+	return r.next.Delete(ctx, id)
+}
+
+func (r *instrumentedOutletRepository) GetProductSettings(ctx context.Context, productID, outletID string) (_ *entity.ProductOutlet, err error) {
+	ctx, done := r.hook(ctx, "outlet", "GetProductSettings")
+	defer func() { done(err) }()
+	return r.next.GetProductSettings(ctx, productID, outletID)
+}
+
+func (r *instrumentedOutletRepository) ListProductSettingsByOutlet(ctx context.Context, outletID string) (_ map[string]*entity.ProductOutlet, err error) {
+	ctx, done := r.hook(ctx, "outlet", "ListProductSettingsByOutlet")
+	defer func() { done(err) }()
+	return r.next.ListProductSettingsByOutlet(ctx, outletID)
+}
+
+func (r *instrumentedOutletRepository) ListProductSettingsByProduct(ctx context.Context, productID string) (_ []*entity.ProductOutlet, err error) {
+	ctx, done := r.hook(ctx, "outlet", "ListProductSettingsByProduct")
+	defer func() { done(err) }()
+	return r.next.ListProductSettingsByProduct(ctx, productID)
+}
+
+func (r *instrumentedOutletRepository) UpsertProductSettings(ctx context.Context, settings *entity.ProductOutlet) (err error) {
+	ctx, done := r.hook(ctx, "outlet", "UpsertProductSettings")
+	defer func() { done(err) }()
+	return r.next.UpsertProductSettings(ctx, settings)
+}
+
+// NewInstrumentedChangeRequestRepository calls hook around every call to next
+func NewInstrumentedChangeRequestRepository(next ChangeRequestRepository, hook QueryHook) ChangeRequestRepository {
+	return &instrumentedChangeRequestRepository{next: next, hook: hook}
+}
+
+type instrumentedChangeRequestRepository struct {
+	next ChangeRequestRepository
+	hook QueryHook
+}
+
+func (r *instrumentedChangeRequestRepository) Create(ctx context.Context, cr *entity.ChangeRequest) (err error) {
+	ctx, done := r.hook(ctx, "change_request", "Create")
+	defer func() { done(err) }()
+	return r.next.Create(ctx, cr)
+}
+
+func (r *instrumentedChangeRequestRepository) GetByID(ctx context.Context, id string) (_ *entity.ChangeRequest, err error) {
+	ctx, done := r.hook(ctx, "change_request", "GetByID")
+	defer func() { done(err) }()
+	return r.next.GetByID(ctx, id)
+}
+
+func (r *instrumentedChangeRequestRepository) List(ctx context.Context, filter ChangeRequestFilter) (_ []*entity.ChangeRequest, err error) {
+	ctx, done := r.hook(ctx, "change_request", "List")
+	defer func() { done(err) }()
+	return r.next.List(ctx, filter)
+}
+
+func (r *instrumentedChangeRequestRepository) UpdateStatus(ctx context.Context, cr *entity.ChangeRequest, from entity.ChangeRequestStatus) (err error) {
+	ctx, done := r.hook(ctx, "change_request", "UpdateStatus")
+	defer func() { done(err) }()
+	return r.next.UpdateStatus(ctx, cr, from)
+}
+
+// NewInstrumentedNotificationRepository calls hook around every call to next
+func NewInstrumentedNotificationRepository(next NotificationRepository, hook QueryHook) NotificationRepository {
+	return &instrumentedNotificationRepository{next: next, hook: hook}
+}
+
+type instrumentedNotificationRepository struct {
+	next NotificationRepository
+	hook QueryHook
+}
+
+func (r *instrumentedNotificationRepository) Create(ctx context.Context, n *entity.Notification) (err error) {
+	ctx, done := r.hook(ctx, "notification", "Create")
+	defer func() { done(err) }()
+	return r.next.Create(ctx, n)
+}
+
+func (r *instrumentedNotificationRepository) ListByUser(ctx context.Context, userID string) (_ []*entity.Notification, err error) {
+	ctx, done := r.hook(ctx, "notification", "ListByUser")
+	defer func() { done(err) }()
+	return r.next.ListByUser(ctx, userID)
+}
+
+func (r *instrumentedNotificationRepository) MarkRead(ctx context.Context, id, userID string) (err error) {
+	ctx, done := r.hook(ctx, "notification", "MarkRead")
+	defer func() { done(err) }()
+	return r.next.MarkRead(ctx, id, userID)
+}
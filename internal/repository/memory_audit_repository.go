@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dominikuswilly/nofu-be_product/internal/entity"
+)
+
+// memoryAuditRepository implements AuditRepository in memory
+type memoryAuditRepository struct {
+	mu      sync.RWMutex
+	entries []*entity.AuditEntry
+}
+
+// NewMemoryAuditRepository creates a new, empty memoryAuditRepository
+func NewMemoryAuditRepository() AuditRepository {
+	return &memoryAuditRepository{}
+}
+
+func (r *memoryAuditRepository) Create(ctx context.Context, entry *entity.AuditEntry) error {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	entry.TenantID = tenantID
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored := *entry
+	stored.CreatedAt = entry.CreatedAt.Truncate(time.Microsecond)
+	r.entries = append(r.entries, &stored)
+	onRollback(ctx, func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.entries = slices.DeleteFunc(r.entries, func(e *entity.AuditEntry) bool { return e == &stored })
+	})
+	return nil
+}
+
+func (r *memoryAuditRepository) ListByProduct(ctx context.Context, productID string) ([]*entity.AuditEntry, error) {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var entries []*entity.AuditEntry
+	for _, e := range r.entries {
+		if e.TenantID == tenantID && e.ProductID == productID {
+			c := *e
+			entries = append(entries, &c)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if !entries[i].CreatedAt.Equal(entries[j].CreatedAt) {
+			return entries[i].CreatedAt.After(entries[j].CreatedAt)
+		}
+		return entries[i].ID > entries[j].ID
+	})
+	return entries, nil
+}
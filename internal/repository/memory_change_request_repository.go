@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dominikuswilly/nofu-be_product/internal/entity"
+)
+
+// memoryChangeRequestRepository implements ChangeRequestRepository in memory
+type memoryChangeRequestRepository struct {
+	mu       sync.RWMutex
+	requests map[memoryKey]*entity.ChangeRequest
+}
+
+// NewMemoryChangeRequestRepository creates a new, empty memoryChangeRequestRepository
+func NewMemoryChangeRequestRepository() ChangeRequestRepository {
+	return &memoryChangeRequestRepository{requests: map[memoryKey]*entity.ChangeRequest{}}
+}
+
+func (r *memoryChangeRequestRepository) Create(ctx context.Context, cr *entity.ChangeRequest) error {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	cr.TenantID = tenantID
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored := *cr
+	stored.CreatedAt = cr.CreatedAt.Truncate(time.Microsecond)
+	stored.ReviewedBy, stored.ReviewComment, stored.ReviewedAt = "", "", nil
+	keepForRollback(ctx, &r.mu, r.requests, memoryKey{tenantID, cr.ID}, shallowClone)
+	r.requests[memoryKey{tenantID, cr.ID}] = &stored
+	return nil
+}
+
+func (r *memoryChangeRequestRepository) GetByID(ctx context.Context, id string) (*entity.ChangeRequest, error) {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	cr, ok := r.requests[memoryKey{tenantID, id}]
+	if !ok {
+		return nil, nil
+	}
+	c := *cr
+	return &c, nil
+}
+
+func (r *memoryChangeRequestRepository) List(ctx context.Context, filter ChangeRequestFilter) ([]*entity.ChangeRequest, error) {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var requests []*entity.ChangeRequest
+	for key, cr := range r.requests {
+		if key.tenantID != tenantID ||
+			(filter.Status != "" && cr.Status != filter.Status) ||
+			(filter.ProductID != "" && cr.ProductID != filter.ProductID) {
+			continue
+		}
+		c := *cr
+		requests = append(requests, &c)
+	}
+	sort.Slice(requests, func(i, j int) bool {
+		if !requests[i].CreatedAt.Equal(requests[j].CreatedAt) {
+			return requests[i].CreatedAt.After(requests[j].CreatedAt)
+		}
+		return requests[i].ID > requests[j].ID
+	})
+	return requests, nil
+}
+
+func (r *memoryChangeRequestRepository) UpdateStatus(ctx context.Context, cr *entity.ChangeRequest, from entity.ChangeRequestStatus) error {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.requests[memoryKey{tenantID, cr.ID}]
+	if !ok || stored.Status != from {
+		return ErrChangeRequestClosed
+	}
+	keepForRollback(ctx, &r.mu, r.requests, memoryKey{tenantID, cr.ID}, shallowClone)
+	stored.Status = cr.Status
+	stored.ReviewedBy = cr.ReviewedBy
+	stored.ReviewComment = cr.ReviewComment
+	stored.ReviewedAt = cloneTime(cr.ReviewedAt)
+	return nil
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dominikuswilly/nofu-be_product/internal/entity"
+)
+
+// memoryNotificationRepository implements NotificationRepository in memory
+type memoryNotificationRepository struct {
+	mu            sync.RWMutex
+	notifications map[memoryKey]*entity.Notification
+}
+
+// NewMemoryNotificationRepository creates a new, empty memoryNotificationRepository
+func NewMemoryNotificationRepository() NotificationRepository {
+	return &memoryNotificationRepository{notifications: map[memoryKey]*entity.Notification{}}
+}
+
+func (r *memoryNotificationRepository) Create(ctx context.Context, n *entity.Notification) error {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	n.TenantID = tenantID
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored := *n
+	stored.CreatedAt = n.CreatedAt.Truncate(time.Microsecond)
+	stored.ReadAt = nil
+	keepForRollback(ctx, &r.mu, r.notifications, memoryKey{tenantID, n.ID}, shallowClone)
+	r.notifications[memoryKey{tenantID, n.ID}] = &stored
+	return nil
+}
+
+func (r *memoryNotificationRepository) ListByUser(ctx context.Context, userID string) ([]*entity.Notification, error) {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var notifications []*entity.Notification
+	for key, n := range r.notifications {
+		if key.tenantID == tenantID && n.UserID == userID {
+			c := *n
+			notifications = append(notifications, &c)
+		}
+	}
+	sort.Slice(notifications, func(i, j int) bool {
+		if !notifications[i].CreatedAt.Equal(notifications[j].CreatedAt) {
+			return notifications[i].CreatedAt.After(notifications[j].CreatedAt)
+		}
+		return notifications[i].ID > notifications[j].ID
+	})
+	return notifications, nil
+}
+
+func (r *memoryNotificationRepository) MarkRead(ctx context.Context, id, userID string) error {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n, ok := r.notifications[memoryKey{tenantID, id}]
+	if !ok || n.UserID != userID {
+		return ErrNotificationNotFound
+	}
+	if n.ReadAt == nil {
+		keepForRollback(ctx, &r.mu, r.notifications, memoryKey{tenantID, id}, shallowClone)
+		now := memoryNow()
+		n.ReadAt = &now
+	}
+	return nil
+}
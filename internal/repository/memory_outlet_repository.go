@@ -0,0 +1,220 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dominikuswilly/nofu-be_product/internal/entity"
+)
+
+// memorySettingsKey identifies the settings of a product at an outlet
+type memorySettingsKey struct {
+	tenantID  string
+	productID string
+	outletID  string
+}
+
+// memoryOutletRepository implements OutletRepository in memory
+type memoryOutletRepository struct {
+	mu       sync.RWMutex
+	outlets  map[memoryKey]*entity.Outlet
+	settings map[memorySettingsKey]*entity.ProductOutlet
+}
+
+// NewMemoryOutletRepository creates a new, empty memoryOutletRepository
+func NewMemoryOutletRepository() OutletRepository {
+	return &memoryOutletRepository{
+		outlets:  map[memoryKey]*entity.Outlet{},
+		settings: map[memorySettingsKey]*entity.ProductOutlet{},
+	}
+}
+
+func (r *memoryOutletRepository) Create(ctx context.Context, outlet *entity.Outlet) error {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	outlet.TenantID = tenantID
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored := *outlet
+	stored.CreatedAt = outlet.CreatedAt.Truncate(time.Microsecond)
+	stored.UpdatedAt = time.Time{}
+	keepForRollback(ctx, &r.mu, r.outlets, memoryKey{tenantID, outlet.ID}, shallowClone)
+	r.outlets[memoryKey{tenantID, outlet.ID}] = &stored
+	return nil
+}
+
+func (r *memoryOutletRepository) GetByID(ctx context.Context, id string) (*entity.Outlet, error) {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	outlet, ok := r.outlets[memoryKey{tenantID, id}]
+	if !ok {
+		return nil, nil
+	}
+	c := *outlet
+	return &c, nil
+}
+
+func (r *memoryOutletRepository) GetAll(ctx context.Context) ([]*entity.Outlet, error) {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var outlets []*entity.Outlet
+	for key, outlet := range r.outlets {
+		if key.tenantID == tenantID {
+			c := *outlet
+			outlets = append(outlets, &c)
+		}
+	}
+	sort.Slice(outlets, func(i, j int) bool { return outlets[i].Name < outlets[j].Name })
+	return outlets, nil
+}
+
+func (r *memoryOutletRepository) Update(ctx context.Context, outlet *entity.Outlet) error {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.outlets[memoryKey{tenantID, outlet.ID}]
+	if !ok {
+		return ErrOutletNotFound
+	}
+	keepForRollback(ctx, &r.mu, r.outlets, memoryKey{tenantID, outlet.ID}, shallowClone)
+	outlet.UpdatedAt = time.Now()
+	stored.Name = outlet.Name
+	stored.Address = outlet.Address
+	stored.Timezone = outlet.Timezone
+	stored.Active = outlet.Active
+	stored.UpdatedAt = outlet.UpdatedAt.Truncate(time.Microsecond)
+	return nil
+}
+
+func (r *memoryOutletRepository) Delete(ctx context.Context, id string) error {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := memoryKey{tenantID, id}
+	if _, ok := r.outlets[key]; !ok {
+		return ErrOutletNotFound
+	}
+	keepForRollback(ctx, &r.mu, r.outlets, key, shallowClone)
+	delete(r.outlets, key)
+	// Settings at the outlet go with it, like the ON DELETE CASCADE foreign key
+	for k := range r.settings {
+		if k.tenantID == tenantID && k.outletID == id {
+			keepForRollback(ctx, &r.mu, r.settings, k, cloneProductOutlet)
+			delete(r.settings, k)
+		}
+	}
+	return nil
+}
+
+func (r *memoryOutletRepository) GetProductSettings(ctx context.Context, productID, outletID string) (*entity.ProductOutlet, error) {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	s, ok := r.settings[memorySettingsKey{tenantID, productID, outletID}]
+	if !ok {
+		return nil, nil
+	}
+	return cloneProductOutlet(s), nil
+}
+
+func (r *memoryOutletRepository) ListProductSettingsByOutlet(ctx context.Context, outletID string) (map[string]*entity.ProductOutlet, error) {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	settings := map[string]*entity.ProductOutlet{}
+	for k, s := range r.settings {
+		if k.tenantID == tenantID && k.outletID == outletID {
+			settings[k.productID] = cloneProductOutlet(s)
+		}
+	}
+	return settings, nil
+}
+
+func (r *memoryOutletRepository) ListProductSettingsByProduct(ctx context.Context, productID string) ([]*entity.ProductOutlet, error) {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var settings []*entity.ProductOutlet
+	for k, s := range r.settings {
+		if k.tenantID == tenantID && k.productID == productID {
+			settings = append(settings, cloneProductOutlet(s))
+		}
+	}
+	sort.Slice(settings, func(i, j int) bool { return settings[i].OutletID < settings[j].OutletID })
+	return settings, nil
+}
+
+func (r *memoryOutletRepository) UpsertProductSettings(ctx context.Context, settings *entity.ProductOutlet) error {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	settings.TenantID = tenantID
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	settings.UpdatedAt = time.Now()
+	stored := cloneProductOutlet(settings)
+	stored.UpdatedAt = settings.UpdatedAt.Truncate(time.Microsecond)
+	key := memorySettingsKey{tenantID, settings.ProductID, settings.OutletID}
+	keepForRollback(ctx, &r.mu, r.settings, key, cloneProductOutlet)
+	r.settings[key] = stored
+	return nil
+}
+
+func cloneProductOutlet(s *entity.ProductOutlet) *entity.ProductOutlet {
+	c := *s
+	if s.PriceOverride != nil {
+		price := *s.PriceOverride
+		c.PriceOverride = &price
+	}
+	if s.Stock != nil {
+		stock := *s.Stock
+		c.Stock = &stock
+	}
+	return &c
+}
@@ -0,0 +1,323 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dominikuswilly/nofu-be_product/internal/entity"
+)
+
+// memoryKey identifies a row of a tenant in the in-memory repositories
+type memoryKey struct {
+	tenantID string
+	id       string
+}
+
+// memoryProductRepository implements ProductRepository in memory. It mirrors the PostgreSQL
+// semantics, including which columns Create stores and microsecond timestamp precision.
+type memoryProductRepository struct {
+	mu       sync.RWMutex
+	products map[memoryKey]*entity.Product
+}
+
+// NewMemoryProductRepository creates a new, empty memoryProductRepository
+func NewMemoryProductRepository() ProductRepository {
+	return &memoryProductRepository{products: map[memoryKey]*entity.Product{}}
+}
+
+func (r *memoryProductRepository) Create(ctx context.Context, product *entity.Product) error {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	product.TenantID = tenantID
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := memoryKey{tenantID, product.ID}
+	if r.idTaken(product.ID) {
+		return ErrDuplicateProduct
+	}
+
+	keepForRollback(ctx, &r.mu, r.products, key, cloneProduct)
+	// Like the INSERT, only the creation columns are stored; the rest take their defaults
+	r.products[key] = cloneProduct(&entity.Product{
+		ID:           product.ID,
+		TenantID:     tenantID,
+		Name:         product.Name,
+		Description:  product.Description,
+		Price:        product.Price,
+		Currency:     product.Currency,
+		Url:          product.Url,
+		CreatedBy:    product.CreatedBy,
+		Stock:        product.Stock,
+		Status:       product.Status,
+		Availability: product.Availability,
+		CreatedAt:    memoryNow(),
+	})
+	return nil
+}
+
+func (r *memoryProductRepository) GetByID(ctx context.Context, id string) (*entity.Product, error) {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	p, ok := r.products[memoryKey{tenantID, id}]
+	if !ok {
+		return nil, nil
+	}
+	return cloneProduct(p), nil
+}
+
+// GetByIDForUpdate needs no lock, the memory transactor runs transactions one at a time
+func (r *memoryProductRepository) GetByIDForUpdate(ctx context.Context, id string) (*entity.Product, error) {
+	return r.GetByID(ctx, id)
+}
+
+func (r *memoryProductRepository) GetAll(ctx context.Context, filter ProductFilter) ([]*entity.Product, error) {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var products []*entity.Product
+	for key, p := range r.products {
+		if key.tenantID != tenantID || (filter.Status != "" && p.Status != filter.Status) {
+			continue
+		}
+		products = append(products, cloneProduct(p))
+	}
+	sort.Slice(products, func(i, j int) bool { return products[i].ID < products[j].ID })
+	return products, nil
+}
+
+func (r *memoryProductRepository) Update(ctx context.Context, product *entity.Product) error {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.products[memoryKey{tenantID, product.ID}]
+	if !ok {
+		return ErrProductNotFound
+	}
+	keepForRollback(ctx, &r.mu, r.products, memoryKey{tenantID, product.ID}, cloneProduct)
+	product.UpdatedAt = time.Now()
+	updated := cloneProduct(stored)
+	updated.Name = product.Name
+	updated.Description = product.Description
+	updated.Price = product.Price
+	updated.UpdatedAt = product.UpdatedAt.Truncate(time.Microsecond)
+	updated.UpdatedBy = product.UpdatedBy
+	updated.Availability = cloneAvailability(product.Availability)
+	r.products[memoryKey{tenantID, product.ID}] = updated
+	return nil
+}
+
+func (r *memoryProductRepository) ReplaceImage(ctx context.Context, product *entity.Product) error {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.products[memoryKey{tenantID, product.ID}]
+	if !ok {
+		return ErrProductNotFound
+	}
+
+	keepForRollback(ctx, &r.mu, r.products, memoryKey{tenantID, product.ID}, cloneProduct)
+	product.UpdatedAt = time.Now()
+	updated := cloneProduct(stored)
+	updated.Url = product.Url
+	updated.Images = cloneImages(product.Images)
+	updated.UpdatedAt = product.UpdatedAt.Truncate(time.Microsecond)
+	updated.UpdatedBy = product.UpdatedBy
+	r.products[memoryKey{tenantID, product.ID}] = updated
+	return nil
+}
+
+func (r *memoryProductRepository) UpdateImages(ctx context.Context, id, sourceUrl string, images map[string]entity.ProductImage) error {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Stale results from an older upload are ignored, as in the PostgreSQL implementation
+	if p, ok := r.products[memoryKey{tenantID, id}]; ok && p.Url == sourceUrl {
+		keepForRollback(ctx, &r.mu, r.products, memoryKey{tenantID, id}, cloneProduct)
+		p.Images = cloneImages(images)
+	}
+	return nil
+}
+
+func (r *memoryProductRepository) AdjustStock(ctx context.Context, id string, delta int64, updatedBy string) (int64, error) {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p, ok := r.products[memoryKey{tenantID, id}]
+	if !ok {
+		return 0, ErrProductNotFound
+	}
+	if p.Stock+delta < 0 {
+		return 0, ErrInsufficientStock
+	}
+	keepForRollback(ctx, &r.mu, r.products, memoryKey{tenantID, id}, cloneProduct)
+	p.Stock += delta
+	p.UpdatedAt = memoryNow()
+	p.UpdatedBy = updatedBy
+	return p.Stock, nil
+}
+
+func (r *memoryProductRepository) UpdateStatus(ctx context.Context, product *entity.Product, from entity.ProductStatus) error {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	product.UpdatedAt = time.Now()
+	p, ok := r.products[memoryKey{tenantID, product.ID}]
+	if !ok || p.Status != from {
+		return ErrStatusConflict
+	}
+	keepForRollback(ctx, &r.mu, r.products, memoryKey{tenantID, product.ID}, cloneProduct)
+	p.Status = product.Status
+	p.SubmittedAt = cloneTime(product.SubmittedAt)
+	p.PublishedAt = cloneTime(product.PublishedAt)
+	p.ArchivedAt = cloneTime(product.ArchivedAt)
+	p.UpdatedAt = product.UpdatedAt.Truncate(time.Microsecond)
+	p.UpdatedBy = product.UpdatedBy
+	return nil
+}
+
+func (r *memoryProductRepository) Delete(ctx context.Context, id string) error {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := memoryKey{tenantID, id}
+	if _, ok := r.products[key]; !ok {
+		return ErrProductNotFound
+	}
+	keepForRollback(ctx, &r.mu, r.products, key, cloneProduct)
+	delete(r.products, key)
+	return nil
+}
+
+func (r *memoryProductRepository) CountByTenant(ctx context.Context, lowStock int64) ([]ProductCounts, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	byTenant := map[string]*ProductCounts{}
+	for key, p := range r.products {
+		if p.Status != entity.ProductStatusPublished {
+			continue
+		}
+		c, ok := byTenant[key.tenantID]
+		if !ok {
+			c = &ProductCounts{TenantID: key.tenantID}
+			byTenant[key.tenantID] = c
+		}
+		c.Active++
+		if p.Stock <= lowStock {
+			c.LowStock++
+		}
+	}
+
+	counts := make([]ProductCounts, 0, len(byTenant))
+	for _, c := range byTenant {
+		counts = append(counts, *c)
+	}
+	sort.Slice(counts, func(i, j int) bool { return counts[i].TenantID < counts[j].TenantID })
+	return counts, nil
+}
+
+// idTaken reports whether any tenant has a product with id, since product IDs are a global
+// primary key. The caller must hold the lock.
+func (r *memoryProductRepository) idTaken(id string) bool {
+	for key := range r.products {
+		if key.id == id {
+			return true
+		}
+	}
+	return false
+}
+
+// memoryNow returns the current time at the precision PostgreSQL stores
+func memoryNow() time.Time {
+	return time.Now().Truncate(time.Microsecond)
+}
+
+// cloneProduct deep-copies p so callers never share state with the store. Empty images and
+// availability become nil, like the NULL JSONB columns they map to.
+func cloneProduct(p *entity.Product) *entity.Product {
+	c := *p
+	c.Images = cloneImages(p.Images)
+	c.Availability = cloneAvailability(p.Availability)
+	c.SubmittedAt = cloneTime(p.SubmittedAt)
+	c.PublishedAt = cloneTime(p.PublishedAt)
+	c.ArchivedAt = cloneTime(p.ArchivedAt)
+	return &c
+}
+
+func cloneImages(images map[string]entity.ProductImage) map[string]entity.ProductImage {
+	if len(images) == 0 {
+		return nil
+	}
+	c := make(map[string]entity.ProductImage, len(images))
+	for k, v := range images {
+		c[k] = v
+	}
+	return c
+}
+
+func cloneAvailability(rules []entity.AvailabilityRule) []entity.AvailabilityRule {
+	if len(rules) == 0 {
+		return nil
+	}
+	c := make([]entity.AvailabilityRule, len(rules))
+	for i, rule := range rules {
+		c[i] = rule
+		c[i].Weekdays = append([]time.Weekday(nil), rule.Weekdays...)
+	}
+	return c
+}
+
+func cloneTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	c := t.Truncate(time.Microsecond)
+	return &c
+}
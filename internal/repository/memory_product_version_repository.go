@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dominikuswilly/nofu-be_product/internal/entity"
+)
+
+// memoryProductVersionRepository implements ProductVersionRepository in memory
+type memoryProductVersionRepository struct {
+	mu       sync.RWMutex
+	versions map[memoryKey][]*entity.ProductVersion
+}
+
+// NewMemoryProductVersionRepository creates a new, empty memoryProductVersionRepository
+func NewMemoryProductVersionRepository() ProductVersionRepository {
+	return &memoryProductVersionRepository{versions: map[memoryKey][]*entity.ProductVersion{}}
+}
+
+func (r *memoryProductVersionRepository) Create(ctx context.Context, version *entity.ProductVersion) error {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	version.TenantID = tenantID
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := memoryKey{tenantID, version.ProductID}
+	version.Version = len(r.versions[key]) + 1
+	stored := *version
+	stored.Snapshot = *cloneProduct(&version.Snapshot)
+	stored.CreatedAt = version.CreatedAt.Truncate(time.Microsecond)
+	r.versions[key] = append(r.versions[key], &stored)
+	onRollback(ctx, func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.versions[key] = slices.DeleteFunc(r.versions[key], func(v *entity.ProductVersion) bool { return v == &stored })
+	})
+	return nil
+}
+
+func (r *memoryProductVersionRepository) ListByProduct(ctx context.Context, productID string) ([]*entity.ProductVersion, error) {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stored := r.versions[memoryKey{tenantID, productID}]
+	versions := make([]*entity.ProductVersion, 0, len(stored))
+	for _, v := range stored {
+		versions = append(versions, cloneProductVersion(v))
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i].Version > versions[j].Version })
+	return versions, nil
+}
+
+func (r *memoryProductVersionRepository) Get(ctx context.Context, productID string, version int) (*entity.ProductVersion, error) {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stored := r.versions[memoryKey{tenantID, productID}]
+	if version < 1 || version > len(stored) {
+		return nil, nil
+	}
+	return cloneProductVersion(stored[version-1]), nil
+}
+
+func cloneProductVersion(v *entity.ProductVersion) *entity.ProductVersion {
+	c := *v
+	c.Snapshot = *cloneProduct(&v.Snapshot)
+	return &c
+}
@@ -0,0 +1,22 @@
+package repository_test
+
+import (
+	"testing"
+
+	"github.com/dominikuswilly/nofu-be_product/internal/repository"
+	"github.com/dominikuswilly/nofu-be_product/internal/repository/repotest"
+)
+
+func TestMemoryRepositories(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) repotest.Repositories {
+		return repotest.Repositories{
+			Products:       repository.NewMemoryProductRepository(),
+			Audit:          repository.NewMemoryAuditRepository(),
+			Versions:       repository.NewMemoryProductVersionRepository(),
+			Outlets:        repository.NewMemoryOutletRepository(),
+			ChangeRequests: repository.NewMemoryChangeRequestRepository(),
+			Notifications:  repository.NewMemoryNotificationRepository(),
+			Tx:             repository.NewMemoryTransactor(),
+		}
+	})
+}
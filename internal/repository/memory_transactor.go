@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"sync"
+)
+
+// memoryTxKey is the context key of the *memoryTx of a transaction
+type memoryTxKey struct{}
+
+// memoryTx collects how to undo the changes made in a transaction
+type memoryTx struct {
+	undo []func()
+}
+
+// memoryTransactor implements Transactor for the in-memory repositories. Transactions run
+// one at a time and are rolled back by undoing their changes in reverse order. Changes made
+// outside a transaction are not isolated from one in progress, which is fine for the
+// development and test storage it is meant for.
+type memoryTransactor struct {
+	mu sync.Mutex
+}
+
+// NewMemoryTransactor creates a Transactor for the in-memory repositories
+func NewMemoryTransactor() Transactor {
+	return &memoryTransactor{}
+}
+
+func (t *memoryTransactor) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(memoryTxKey{}).(*memoryTx); ok {
+		return fn(ctx)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	tx := &memoryTx{}
+	committed := false
+	defer func() {
+		if !committed {
+			for i := len(tx.undo) - 1; i >= 0; i-- {
+				tx.undo[i]()
+			}
+		}
+	}()
+	if err := fn(context.WithValue(ctx, memoryTxKey{}, tx)); err != nil {
+		return err
+	}
+	committed = true
+	return nil
+}
+
+// onRollback registers undo to run if the transaction in ctx is rolled back. Outside a
+// transaction changes are final and undo is dropped.
+func onRollback(ctx context.Context, undo func()) {
+	if tx, ok := ctx.Value(memoryTxKey{}).(*memoryTx); ok {
+		tx.undo = append(tx.undo, undo)
+	}
+}
+
+// keepForRollback restores m[key] to its current value, or removes it when it is not set,
+// if the transaction in ctx is rolled back. The caller must hold mu.
+func keepForRollback[K comparable, V any](ctx context.Context, mu *sync.RWMutex, m map[K]*V, key K, clone func(*V) *V) {
+	prev, ok := m[key]
+	if ok {
+		prev = clone(prev)
+	}
+	onRollback(ctx, func() {
+		mu.Lock()
+		defer mu.Unlock()
+		if ok {
+			m[key] = prev
+		} else {
+			delete(m, key)
+		}
+	})
+}
+
+// shallowClone copies a row without pointers that are changed in place
+func shallowClone[V any](v *V) *V {
+	c := *v
+	return &c
+}
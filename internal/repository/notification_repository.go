@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/dominikuswilly/nofu-be_product/internal/apperror"
+	"github.com/dominikuswilly/nofu-be_product/internal/entity"
+)
+
+// ErrNotificationNotFound is returned when the user has no notification with the given ID
+var ErrNotificationNotFound = apperror.NotFound("notification_not_found", "Notification not found")
+
+// NotificationRepository defines the interface for user notification data access
+type NotificationRepository interface {
+	Create(ctx context.Context, n *entity.Notification) error
+	ListByUser(ctx context.Context, userID string) ([]*entity.Notification, error)
+	MarkRead(ctx context.Context, id, userID string) error
+}
+
+// sqlNotificationRepository implements NotificationRepository for PostgreSQL and SQLite
+type sqlNotificationRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresNotificationRepository creates a NotificationRepository backed by PostgreSQL
+func NewPostgresNotificationRepository(db *sql.DB) NotificationRepository {
+	return &sqlNotificationRepository{db: db}
+}
+
+func (r *sqlNotificationRepository) Create(ctx context.Context, n *entity.Notification) error {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	n.TenantID = tenantID
+
+	query := `
+		INSERT INTO product_notification (c_id, c_tenant_id, c_user_id, c_type, c_message, c_product_id, c_change_request_id, ts_created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+	_, err = conn(ctx, r.db).ExecContext(ctx, query,
+		n.ID,
+		n.TenantID,
+		n.UserID,
+		n.Type,
+		n.Message,
+		n.ProductID,
+		n.ChangeRequestID,
+		n.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create notification: %w", err)
+	}
+	return nil
+}
+
+// ListByUser returns the notifications of userID, newest first
+func (r *sqlNotificationRepository) ListByUser(ctx context.Context, userID string) ([]*entity.Notification, error) {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT c_id, c_tenant_id, c_user_id, c_type, c_message, c_product_id, c_change_request_id, ts_created_at, ts_read_at
+		FROM product_notification
+		WHERE c_user_id = $1 AND c_tenant_id = $2
+		ORDER BY ts_created_at DESC, c_id DESC
+	`
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, userID, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notifications: %w", err)
+	}
+	defer rows.Close()
+
+	var notifications []*entity.Notification
+	for rows.Next() {
+		n := &entity.Notification{}
+		if err := rows.Scan(
+			&n.ID,
+			&n.TenantID,
+			&n.UserID,
+			&n.Type,
+			&n.Message,
+			&n.ProductID,
+			&n.ChangeRequestID,
+			&n.CreatedAt,
+			&n.ReadAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan notification: %w", err)
+		}
+		notifications = append(notifications, n)
+	}
+	return notifications, rows.Err()
+}
+
+// MarkRead marks the notification as read. Notifications of other users are reported as not found.
+func (r *sqlNotificationRepository) MarkRead(ctx context.Context, id, userID string) error {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE product_notification
+		SET ts_read_at = COALESCE(ts_read_at, $1)
+		WHERE c_id = $2 AND c_user_id = $3 AND c_tenant_id = $4
+	`
+	res, err := conn(ctx, r.db).ExecContext(ctx, query, time.Now(), id, userID, tenantID)
+	if err != nil {
+		return fmt.Errorf("failed to mark notification as read: %w", err)
+	}
+
+	rowsAffected, _ := res.RowsAffected()
+	if rowsAffected == 0 {
+		return ErrNotificationNotFound
+	}
+	return nil
+}
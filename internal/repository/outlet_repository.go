@@ -0,0 +1,329 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/dominikuswilly/nofu-be_product/internal/apperror"
+	"github.com/dominikuswilly/nofu-be_product/internal/entity"
+)
+
+// ErrOutletNotFound is returned when the tenant has no outlet with the given ID
+var ErrOutletNotFound = apperror.NotFound("outlet_not_found", "Outlet not found")
+
+// OutletRepository defines the interface for outlet and per-outlet product settings data access
+type OutletRepository interface {
+	Create(ctx context.Context, outlet *entity.Outlet) error
+	GetByID(ctx context.Context, id string) (*entity.Outlet, error)
+	GetAll(ctx context.Context) ([]*entity.Outlet, error)
+	Update(ctx context.Context, outlet *entity.Outlet) error
+	Delete(ctx context.Context, id string) error
+
+	GetProductSettings(ctx context.Context, productID, outletID string) (*entity.ProductOutlet, error)
+	ListProductSettingsByOutlet(ctx context.Context, outletID string) (map[string]*entity.ProductOutlet, error)
+	ListProductSettingsByProduct(ctx context.Context, productID string) ([]*entity.ProductOutlet, error)
+	UpsertProductSettings(ctx context.Context, settings *entity.ProductOutlet) error
+}
+
+// sqlOutletRepository implements OutletRepository for PostgreSQL and SQLite
+type sqlOutletRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresOutletRepository creates an OutletRepository backed by PostgreSQL
+func NewPostgresOutletRepository(db *sql.DB) OutletRepository {
+	return &sqlOutletRepository{db: db}
+}
+
+func (r *sqlOutletRepository) Create(ctx context.Context, outlet *entity.Outlet) error {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	outlet.TenantID = tenantID
+
+	query := `
+		INSERT INTO outlet_master (c_id, c_tenant_id, c_nm, c_address, c_timezone, b_active, ts_created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+	_, err = conn(ctx, r.db).ExecContext(ctx, query,
+		outlet.ID,
+		outlet.TenantID,
+		outlet.Name,
+		outlet.Address,
+		outlet.Timezone,
+		outlet.Active,
+		outlet.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create outlet: %w", err)
+	}
+	return nil
+}
+
+func (r *sqlOutletRepository) GetByID(ctx context.Context, id string) (*entity.Outlet, error) {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT c_id, c_tenant_id, c_nm, c_address, c_timezone, b_active, ts_created_at, ts_updated_at
+		FROM outlet_master
+		WHERE c_id = $1 AND c_tenant_id = $2
+	`
+	outlet, err := scanOutlet(conn(ctx, r.db).QueryRowContext(ctx, query, id, tenantID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get outlet by id: %w", err)
+	}
+	return outlet, nil
+}
+
+func (r *sqlOutletRepository) GetAll(ctx context.Context) ([]*entity.Outlet, error) {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT c_id, c_tenant_id, c_nm, c_address, c_timezone, b_active, ts_created_at, ts_updated_at
+		FROM outlet_master
+		WHERE c_tenant_id = $1
+		ORDER BY c_nm ASC
+	`
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get all outlets: %w", err)
+	}
+	defer rows.Close()
+
+	var outlets []*entity.Outlet
+	for rows.Next() {
+		outlet, err := scanOutlet(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan outlet: %w", err)
+		}
+		outlets = append(outlets, outlet)
+	}
+	return outlets, rows.Err()
+}
+
+func (r *sqlOutletRepository) Update(ctx context.Context, outlet *entity.Outlet) error {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE outlet_master
+		SET c_nm = $1, c_address = $2, c_timezone = $3, b_active = $4, ts_updated_at = $5
+		WHERE c_id = $6 AND c_tenant_id = $7
+	`
+	outlet.UpdatedAt = time.Now()
+	res, err := conn(ctx, r.db).ExecContext(ctx, query,
+		outlet.Name,
+		outlet.Address,
+		outlet.Timezone,
+		outlet.Active,
+		outlet.UpdatedAt,
+		outlet.ID,
+		tenantID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update outlet: %w", err)
+	}
+
+	rowsAffected, _ := res.RowsAffected()
+	if rowsAffected == 0 {
+		return ErrOutletNotFound
+	}
+	return nil
+}
+
+func (r *sqlOutletRepository) Delete(ctx context.Context, id string) error {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	query := `DELETE FROM outlet_master WHERE c_id = $1 AND c_tenant_id = $2`
+	res, err := conn(ctx, r.db).ExecContext(ctx, query, id, tenantID)
+	if err != nil {
+		return fmt.Errorf("failed to delete outlet: %w", err)
+	}
+
+	rowsAffected, _ := res.RowsAffected()
+	if rowsAffected == 0 {
+		return ErrOutletNotFound
+	}
+	return nil
+}
+
+func (r *sqlOutletRepository) GetProductSettings(ctx context.Context, productID, outletID string) (*entity.ProductOutlet, error) {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT c_product_id, c_outlet_id, c_tenant_id, d_price_override, i_stock, b_available, b_sold_out, c_updated_by, ts_updated_at
+		FROM product_outlet
+		WHERE c_product_id = $1 AND c_outlet_id = $2 AND c_tenant_id = $3
+	`
+	settings, err := scanProductOutlet(conn(ctx, r.db).QueryRowContext(ctx, query, productID, outletID, tenantID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product outlet settings: %w", err)
+	}
+	return settings, nil
+}
+
+// ListProductSettingsByOutlet returns the settings of every product configured for the outlet, keyed by product ID
+func (r *sqlOutletRepository) ListProductSettingsByOutlet(ctx context.Context, outletID string) (map[string]*entity.ProductOutlet, error) {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT c_product_id, c_outlet_id, c_tenant_id, d_price_override, i_stock, b_available, b_sold_out, c_updated_by, ts_updated_at
+		FROM product_outlet
+		WHERE c_outlet_id = $1 AND c_tenant_id = $2
+	`
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, outletID, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list product outlet settings: %w", err)
+	}
+	defer rows.Close()
+
+	settings := map[string]*entity.ProductOutlet{}
+	for rows.Next() {
+		s, err := scanProductOutlet(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan product outlet settings: %w", err)
+		}
+		settings[s.ProductID] = s
+	}
+	return settings, rows.Err()
+}
+
+func (r *sqlOutletRepository) ListProductSettingsByProduct(ctx context.Context, productID string) ([]*entity.ProductOutlet, error) {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT c_product_id, c_outlet_id, c_tenant_id, d_price_override, i_stock, b_available, b_sold_out, c_updated_by, ts_updated_at
+		FROM product_outlet
+		WHERE c_product_id = $1 AND c_tenant_id = $2
+		ORDER BY c_outlet_id ASC
+	`
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, productID, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list product outlet settings: %w", err)
+	}
+	defer rows.Close()
+
+	var settings []*entity.ProductOutlet
+	for rows.Next() {
+		s, err := scanProductOutlet(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan product outlet settings: %w", err)
+		}
+		settings = append(settings, s)
+	}
+	return settings, rows.Err()
+}
+
+func (r *sqlOutletRepository) UpsertProductSettings(ctx context.Context, settings *entity.ProductOutlet) error {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	settings.TenantID = tenantID
+
+	query := `
+		INSERT INTO product_outlet (c_product_id, c_outlet_id, c_tenant_id, d_price_override, i_stock, b_available, b_sold_out, c_updated_by, ts_updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (c_product_id, c_outlet_id) DO UPDATE
+		SET d_price_override = EXCLUDED.d_price_override, i_stock = EXCLUDED.i_stock, b_available = EXCLUDED.b_available,
+			b_sold_out = EXCLUDED.b_sold_out, c_updated_by = EXCLUDED.c_updated_by, ts_updated_at = EXCLUDED.ts_updated_at
+		WHERE product_outlet.c_tenant_id = EXCLUDED.c_tenant_id
+	`
+	settings.UpdatedAt = time.Now()
+	_, err = conn(ctx, r.db).ExecContext(ctx, query,
+		settings.ProductID,
+		settings.OutletID,
+		settings.TenantID,
+		settings.PriceOverride,
+		settings.Stock,
+		settings.Available,
+		settings.SoldOut,
+		settings.UpdatedBy,
+		settings.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save product outlet settings: %w", err)
+	}
+	return nil
+}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanOutlet(row rowScanner) (*entity.Outlet, error) {
+	outlet := &entity.Outlet{}
+	var updatedAt sql.NullTime
+	if err := row.Scan(
+		&outlet.ID,
+		&outlet.TenantID,
+		&outlet.Name,
+		&outlet.Address,
+		&outlet.Timezone,
+		&outlet.Active,
+		&outlet.CreatedAt,
+		&updatedAt,
+	); err != nil {
+		return nil, err
+	}
+	if updatedAt.Valid {
+		outlet.UpdatedAt = updatedAt.Time
+	}
+	return outlet, nil
+}
+
+func scanProductOutlet(row rowScanner) (*entity.ProductOutlet, error) {
+	s := &entity.ProductOutlet{}
+	var price sql.NullFloat64
+	var stock sql.NullInt64
+	if err := row.Scan(
+		&s.ProductID,
+		&s.OutletID,
+		&s.TenantID,
+		&price,
+		&stock,
+		&s.Available,
+		&s.SoldOut,
+		&s.UpdatedBy,
+		&s.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	if price.Valid {
+		s.PriceOverride = &price.Float64
+	}
+	if stock.Valid {
+		s.Stock = &stock.Int64
+	}
+	return s, nil
+}
@@ -3,40 +3,101 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"time"
 
+	"github.com/dominikuswilly/nofu-be_product/internal/apperror"
 	"github.com/dominikuswilly/nofu-be_product/internal/entity"
+	"github.com/dominikuswilly/nofu-be_product/internal/tenant"
+	"github.com/lib/pq"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+var (
+	// ErrProductNotFound is returned when the tenant has no product with the given ID
+	ErrProductNotFound = apperror.NotFound("product_not_found", "Product not found")
+	// ErrInsufficientStock is returned when a stock adjustment would make stock negative
+	ErrInsufficientStock = apperror.Conflict("insufficient_stock", "Insufficient stock")
+	// ErrDuplicateProduct is returned when a product with the same ID already exists
+	ErrDuplicateProduct = apperror.Conflict("product_already_exists", "Product already exists")
+	// ErrMissingTenant is returned when a query is attempted without a tenant in the context
+	ErrMissingTenant = errors.New("tenant is not set")
+	// ErrStatusConflict is returned when the product status changed since it was read
+	ErrStatusConflict = apperror.Conflict("product_status_changed", "Product status has changed")
 )
 
 // ProductRepository defines the interface for product data access
 type ProductRepository interface {
 	Create(ctx context.Context, product *entity.Product) error
 	GetByID(ctx context.Context, id string) (*entity.Product, error)
-	GetAll(ctx context.Context) ([]*entity.Product, error)
+	// GetByIDForUpdate is GetByID for a read-modify-write. Within a transaction, other
+	// writers of the product wait until it ends, so the product cannot change in between.
+	GetByIDForUpdate(ctx context.Context, id string) (*entity.Product, error)
+	GetAll(ctx context.Context, filter ProductFilter) ([]*entity.Product, error)
+	// Update stores the editable fields of product. The photo and stock are left alone, they
+	// only change through ReplaceImage and UpdateImages, and AdjustStock.
 	Update(ctx context.Context, product *entity.Product) error
+	// ReplaceImage points product at a new photo, e.g. after an upload or a rollback,
+	// replacing its derivatives
+	ReplaceImage(ctx context.Context, product *entity.Product) error
+	UpdateImages(ctx context.Context, id, sourceUrl string, images map[string]entity.ProductImage) error
+	AdjustStock(ctx context.Context, id string, delta int64, updatedBy string) (int64, error)
+	UpdateStatus(ctx context.Context, product *entity.Product, from entity.ProductStatus) error
 	Delete(ctx context.Context, id string) error
+	// CountByTenant summarizes the published products of every tenant for operational
+	// metrics. Unlike every other method it is not scoped to the tenant in ctx.
+	CountByTenant(ctx context.Context, lowStock int64) ([]ProductCounts, error)
 }
 
-// postgresProductRepository implements ProductRepository for PostgreSQL
-type postgresProductRepository struct {
+// ProductCounts summarizes the published products of a tenant
+type ProductCounts struct {
+	TenantID string
+	// Active is the number of published products
+	Active int64
+	// LowStock is the number of published products with at most the low stock threshold in stock
+	LowStock int64
+}
+
+// ProductFilter narrows down the products returned by GetAll
+type ProductFilter struct {
+	// Status keeps only products in that status; empty returns every status
+	Status entity.ProductStatus
+}
+
+// sqlProductRepository implements ProductRepository for PostgreSQL and SQLite
+type sqlProductRepository struct {
 	db *sql.DB
+	// lockClause is appended to the query of GetByIDForUpdate to lock the row
+	lockClause string
 }
 
-// NewPostgresProductRepository creates a new postgresProductRepository
+// NewPostgresProductRepository creates a ProductRepository backed by PostgreSQL
 func NewPostgresProductRepository(db *sql.DB) ProductRepository {
-	return &postgresProductRepository{db: db}
+	return &sqlProductRepository{db: db, lockClause: " FOR UPDATE"}
 }
 
-func (r *postgresProductRepository) Create(ctx context.Context, product *entity.Product) error {
+func (r *sqlProductRepository) Create(ctx context.Context, product *entity.Product) error {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	product.TenantID = tenantID
+
 	query := `
-		INSERT INTO product_master (c_id, c_nm, c_description, d_price, c_currency, c_url, c_created_by, i_stock, i_active)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		INSERT INTO product_master (c_id, c_tenant_id, c_nm, c_description, d_price, c_currency, c_url, c_created_by, i_stock, c_status, j_availability)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 	`
+	availability, err := encodeJSON(product.Availability, len(product.Availability) == 0)
+	if err != nil {
+		return err
+	}
 
-	err := r.db.QueryRowContext(ctx, query,
+	_, err = conn(ctx, r.db).ExecContext(ctx, query,
 		product.ID,
+		product.TenantID,
 		product.Name,
 		product.Description,
 		product.Price,
@@ -44,36 +105,60 @@ func (r *postgresProductRepository) Create(ctx context.Context, product *entity.
 		product.Url,
 		product.CreatedBy,
 		product.Stock,
-		product.Active,
-	).Err()
+		product.Status,
+		availability,
+	)
 
+	if isUniqueViolation(err) {
+		return ErrDuplicateProduct
+	}
 	if err != nil {
 		return fmt.Errorf("failed to create product: %w", err)
 	}
 	return nil
 }
 
-func (r *postgresProductRepository) GetByID(ctx context.Context, id string) (*entity.Product, error) {
+func (r *sqlProductRepository) GetByID(ctx context.Context, id string) (*entity.Product, error) {
+	return r.getByID(ctx, id, "")
+}
+
+func (r *sqlProductRepository) GetByIDForUpdate(ctx context.Context, id string) (*entity.Product, error) {
+	return r.getByID(ctx, id, r.lockClause)
+}
+
+func (r *sqlProductRepository) getByID(ctx context.Context, id, lockClause string) (*entity.Product, error) {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	query := `
-		SELECT c_id, c_nm, c_description, d_price, c_currency, c_url, c_created_by, ts_created_at, ts_updated_at, i_stock, i_active
+		SELECT c_id, c_tenant_id, c_nm, c_description, d_price, c_currency, c_url, j_images, j_availability, c_created_by, c_updated_by, ts_created_at, ts_updated_at, i_stock, c_status, ts_submitted_at, ts_published_at, ts_archived_at
 		FROM product_master
-		WHERE c_id = $1
-	`
+		WHERE c_id = $1 AND c_tenant_id = $2` + lockClause
 	product := &entity.Product{}
 	var createdAt, updatedAt sql.NullTime
+	var images, availability []byte
 	// var createdBy sql.NullString // Removed, scanning directly into product.CreatedBy
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
+	err = conn(ctx, r.db).QueryRowContext(ctx, query, id, tenantID).Scan(
 		&product.ID,
+		&product.TenantID,
 		&product.Name,
 		&product.Description,
 		&product.Price,
 		&product.Currency,
 		&product.Url,
+		&images,
+		&availability,
 		&product.CreatedBy, // Scan directly into *string
+		&product.UpdatedBy,
 		&createdAt,
 		&updatedAt,
 		&product.Stock,
-		&product.Active,
+		&product.Status,
+		&product.SubmittedAt,
+		&product.PublishedAt,
+		&product.ArchivedAt,
 	)
 
 	if err == sql.ErrNoRows {
@@ -90,16 +175,28 @@ func (r *postgresProductRepository) GetByID(ctx context.Context, id string) (*en
 	if updatedAt.Valid {
 		product.UpdatedAt = updatedAt.Time
 	}
+	if err := decodeJSON(images, &product.Images); err != nil {
+		return nil, err
+	}
+	if err := decodeJSON(availability, &product.Availability); err != nil {
+		return nil, err
+	}
 	return product, nil
 }
 
-func (r *postgresProductRepository) GetAll(ctx context.Context) ([]*entity.Product, error) {
+func (r *sqlProductRepository) GetAll(ctx context.Context, filter ProductFilter) ([]*entity.Product, error) {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	query := `
-		SELECT c_id, c_nm, c_description, d_price, c_currency, c_url, c_created_by, ts_created_at, ts_updated_at, i_stock, i_active
+		SELECT c_id, c_tenant_id, c_nm, c_description, d_price, c_currency, c_url, j_images, j_availability, c_created_by, c_updated_by, ts_created_at, ts_updated_at, i_stock, c_status, ts_submitted_at, ts_published_at, ts_archived_at
 		FROM product_master
+		WHERE c_tenant_id = $1 AND ($2 = '' OR c_status = $2)
 		ORDER BY c_id ASC
 	`
-	rows, err := r.db.QueryContext(ctx, query)
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, tenantID, filter.Status)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get all products: %w", err)
 	}
@@ -109,19 +206,27 @@ func (r *postgresProductRepository) GetAll(ctx context.Context) ([]*entity.Produ
 	for rows.Next() {
 		product := &entity.Product{}
 		var createdAt, updatedAt sql.NullTime
+		var images, availability []byte
 		// var createdBy sql.NullString // Removed
 		if err := rows.Scan(
 			&product.ID,
+			&product.TenantID,
 			&product.Name,
 			&product.Description,
 			&product.Price,
 			&product.Currency,
 			&product.Url,
+			&images,
+			&availability,
 			&product.CreatedBy, // Scan directly into *string
+			&product.UpdatedBy,
 			&createdAt,
 			&updatedAt,
 			&product.Stock,
-			&product.Active,
+			&product.Status,
+			&product.SubmittedAt,
+			&product.PublishedAt,
+			&product.ArchivedAt,
 		); err != nil {
 			return nil, fmt.Errorf("failed to scan product: %w", err)
 		}
@@ -132,26 +237,42 @@ func (r *postgresProductRepository) GetAll(ctx context.Context) ([]*entity.Produ
 		if updatedAt.Valid {
 			product.UpdatedAt = updatedAt.Time
 		}
+		if err := decodeJSON(images, &product.Images); err != nil {
+			return nil, err
+		}
+		if err := decodeJSON(availability, &product.Availability); err != nil {
+			return nil, err
+		}
 		products = append(products, product)
 	}
-	return products, nil
+	return products, rows.Err()
 }
 
-func (r *postgresProductRepository) Update(ctx context.Context, product *entity.Product) error {
+func (r *sqlProductRepository) Update(ctx context.Context, product *entity.Product) error {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
 	query := `
 		UPDATE product_master
-		SET c_nm = $1, c_description = $2, d_price = $3, i_stock = $4, ts_updated_at = $5, i_active = $6
-		WHERE c_id = $7
+		SET c_nm = $1, c_description = $2, d_price = $3, ts_updated_at = $4, c_updated_by = $5, j_availability = $6
+		WHERE c_id = $7 AND c_tenant_id = $8
 	`
+	availability, err := encodeJSON(product.Availability, len(product.Availability) == 0)
+	if err != nil {
+		return err
+	}
 	product.UpdatedAt = time.Now()
-	res, err := r.db.ExecContext(ctx, query,
+	res, err := conn(ctx, r.db).ExecContext(ctx, query,
 		product.Name,
 		product.Description,
 		product.Price,
-		product.Stock,
 		product.UpdatedAt,
-		product.Active,
+		product.UpdatedBy,
+		availability,
 		product.ID,
+		tenantID,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to update product: %w", err)
@@ -159,21 +280,218 @@ func (r *postgresProductRepository) Update(ctx context.Context, product *entity.
 
 	rowsAffected, _ := res.RowsAffected()
 	if rowsAffected == 0 {
-		return errors.New("product not found")
+		return ErrProductNotFound
+	}
+	return nil
+}
+
+func (r *sqlProductRepository) ReplaceImage(ctx context.Context, product *entity.Product) error {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE product_master
+		SET c_url = $1, j_images = $2, ts_updated_at = $3, c_updated_by = $4
+		WHERE c_id = $5 AND c_tenant_id = $6
+	`
+	images, err := encodeJSON(product.Images, len(product.Images) == 0)
+	if err != nil {
+		return err
+	}
+	product.UpdatedAt = time.Now()
+	res, err := conn(ctx, r.db).ExecContext(ctx, query, product.Url, images, product.UpdatedAt, product.UpdatedBy, product.ID, tenantID)
+	if err != nil {
+		return fmt.Errorf("failed to replace product image: %w", err)
+	}
+
+	rowsAffected, _ := res.RowsAffected()
+	if rowsAffected == 0 {
+		return ErrProductNotFound
+	}
+	return nil
+}
+
+// UpdateImages stores the generated image derivatives, as long as the product still points
+// at the image they were generated from. Stale results from an older upload are ignored.
+func (r *sqlProductRepository) UpdateImages(ctx context.Context, id, sourceUrl string, images map[string]entity.ProductImage) error {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE product_master
+		SET j_images = $1
+		WHERE c_id = $2 AND c_url = $3 AND c_tenant_id = $4
+	`
+	encoded, err := encodeJSON(images, len(images) == 0)
+	if err != nil {
+		return err
+	}
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, encoded, id, sourceUrl, tenantID); err != nil {
+		return fmt.Errorf("failed to update product images: %w", err)
 	}
 	return nil
 }
 
-func (r *postgresProductRepository) Delete(ctx context.Context, id string) error {
-	query := `DELETE FROM product_master WHERE c_id = $1`
-	res, err := r.db.ExecContext(ctx, query, id)
+// AdjustStock atomically adds delta to the stock and returns the new level.
+// It refuses to let stock go below zero.
+func (r *sqlProductRepository) AdjustStock(ctx context.Context, id string, delta int64, updatedBy string) (int64, error) {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	query := `
+		UPDATE product_master
+		SET i_stock = i_stock + $1, ts_updated_at = $2, c_updated_by = $3
+		WHERE c_id = $4 AND c_tenant_id = $5 AND i_stock + $1 >= 0
+		RETURNING i_stock
+	`
+	var stock int64
+	err = conn(ctx, r.db).QueryRowContext(ctx, query, delta, time.Now(), updatedBy, id, tenantID).Scan(&stock)
+	if err == sql.ErrNoRows {
+		var exists bool
+		if err := conn(ctx, r.db).QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM product_master WHERE c_id = $1 AND c_tenant_id = $2)`, id, tenantID).Scan(&exists); err != nil {
+			return 0, fmt.Errorf("failed to adjust stock: %w", err)
+		}
+		if !exists {
+			return 0, ErrProductNotFound
+		}
+		return 0, ErrInsufficientStock
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to adjust stock: %w", err)
+	}
+	return stock, nil
+}
+
+// UpdateStatus stores the status and transition timestamps of product, as long as its
+// status is still from. This keeps concurrent transitions from overwriting each other.
+func (r *sqlProductRepository) UpdateStatus(ctx context.Context, product *entity.Product, from entity.ProductStatus) error {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE product_master
+		SET c_status = $1, ts_submitted_at = $2, ts_published_at = $3, ts_archived_at = $4, ts_updated_at = $5, c_updated_by = $6
+		WHERE c_id = $7 AND c_tenant_id = $8 AND c_status = $9
+	`
+	product.UpdatedAt = time.Now()
+	res, err := conn(ctx, r.db).ExecContext(ctx, query,
+		product.Status,
+		product.SubmittedAt,
+		product.PublishedAt,
+		product.ArchivedAt,
+		product.UpdatedAt,
+		product.UpdatedBy,
+		product.ID,
+		tenantID,
+		from,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update product status: %w", err)
+	}
+
+	rowsAffected, _ := res.RowsAffected()
+	if rowsAffected == 0 {
+		return ErrStatusConflict
+	}
+	return nil
+}
+
+func (r *sqlProductRepository) Delete(ctx context.Context, id string) error {
+	tenantID, err := tenantFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	query := `DELETE FROM product_master WHERE c_id = $1 AND c_tenant_id = $2`
+	res, err := conn(ctx, r.db).ExecContext(ctx, query, id, tenantID)
 	if err != nil {
 		return fmt.Errorf("failed to delete product: %w", err)
 	}
 
 	rowsAffected, _ := res.RowsAffected()
 	if rowsAffected == 0 {
-		return errors.New("product not found")
+		return ErrProductNotFound
+	}
+	return nil
+}
+
+func (r *sqlProductRepository) CountByTenant(ctx context.Context, lowStock int64) ([]ProductCounts, error) {
+	query := `
+		SELECT c_tenant_id, COUNT(*), SUM(CASE WHEN i_stock <= $1 THEN 1 ELSE 0 END)
+		FROM product_master
+		WHERE c_status = $2
+		GROUP BY c_tenant_id
+		ORDER BY c_tenant_id ASC
+	`
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, lowStock, entity.ProductStatusPublished)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count products: %w", err)
+	}
+	defer rows.Close()
+
+	var counts []ProductCounts
+	for rows.Next() {
+		var c ProductCounts
+		if err := rows.Scan(&c.TenantID, &c.Active, &c.LowStock); err != nil {
+			return nil, fmt.Errorf("failed to scan product counts: %w", err)
+		}
+		counts = append(counts, c)
+	}
+	return counts, rows.Err()
+}
+
+// encodeJSON serializes v for a JSONB column, storing NULL when isEmpty. It returns a string
+// rather than []byte because lib/pq sends []byte parameters in binary format, which JSONB rejects.
+func encodeJSON(v interface{}, isEmpty bool) (sql.NullString, error) {
+	if isEmpty {
+		return sql.NullString{}, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return sql.NullString{}, fmt.Errorf("failed to encode JSON column: %w", err)
+	}
+	return sql.NullString{String: string(b), Valid: true}, nil
+}
+
+// decodeJSON decodes a nullable JSONB column into v, leaving v untouched for NULL
+func decodeJSON(b []byte, v interface{}) error {
+	if len(b) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		return fmt.Errorf("failed to decode JSON column: %w", err)
 	}
 	return nil
 }
+
+// tenantFromContext returns the tenant every query must be scoped to. Queries without a
+// tenant are refused rather than silently reading across tenants.
+func tenantFromContext(ctx context.Context) (string, error) {
+	id, ok := tenant.FromContext(ctx)
+	if !ok {
+		return "", ErrMissingTenant
+	}
+	return id, nil
+}
+
+// isUniqueViolation reports whether err is a PostgreSQL or SQLite unique constraint violation
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code == "23505"
+	}
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		code := sqliteErr.Code()
+		return code == sqlite3.SQLITE_CONSTRAINT_UNIQUE || code == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY
+	}
+	return false
+}
@@ -0,0 +1,920 @@
+// Package repotest is a conformance suite for repository backends. Every backend must pass
+// it so that the service behaves the same whichever storage it runs on.
+package repotest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/dominikuswilly/nofu-be_product/internal/entity"
+	"github.com/dominikuswilly/nofu-be_product/internal/repository"
+	"github.com/dominikuswilly/nofu-be_product/internal/tenant"
+	"github.com/google/uuid"
+)
+
+// Repositories is a set of repositories sharing the same storage
+type Repositories struct {
+	Products       repository.ProductRepository
+	Audit          repository.AuditRepository
+	Versions       repository.ProductVersionRepository
+	Outlets        repository.OutletRepository
+	ChangeRequests repository.ChangeRequestRepository
+	Notifications  repository.NotificationRepository
+	// Tx runs transactions across the repositories above
+	Tx repository.Transactor
+}
+
+// Run runs the conformance suite. newRepos is called once per test and may return
+// repositories over shared storage; every test works in a tenant of its own.
+func Run(t *testing.T, newRepos func(t *testing.T) Repositories) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, ctx context.Context, r Repositories)
+	}{
+		{"MissingTenant", testMissingTenant},
+		{"ProductCreateAndGet", testProductCreateAndGet},
+		{"ProductTenantIsolation", testProductTenantIsolation},
+		{"ProductSharedName", testProductSharedName},
+		{"ProductDuplicateID", testProductDuplicateID},
+		{"ProductGetAll", testProductGetAll},
+		{"ProductGetAllCancelled", testProductGetAllCancelled},
+		{"ProductUpdate", testProductUpdate},
+		{"ProductReplaceImage", testProductReplaceImage},
+		{"ProductUpdateImages", testProductUpdateImages},
+		{"ProductAdjustStock", testProductAdjustStock},
+		{"ProductUpdateStatus", testProductUpdateStatus},
+		{"ProductDelete", testProductDelete},
+		{"ProductCounts", testProductCounts},
+		{"Audit", testAudit},
+		{"Versions", testVersions},
+		{"Outlets", testOutlets},
+		{"ProductSettings", testProductSettings},
+		{"ChangeRequests", testChangeRequests},
+		{"Notifications", testNotifications},
+		{"TransactionCommit", testTransactionCommit},
+		{"TransactionRollback", testTransactionRollback},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, newTenant(), newRepos(t))
+		})
+	}
+}
+
+func newTenant() context.Context {
+	return tenant.WithID(context.Background(), "repotest-"+uuid.NewString()[:8])
+}
+
+func newProduct(name string) *entity.Product {
+	return &entity.Product{
+		ID:          uuid.NewString(),
+		Name:        name,
+		Description: name + " description",
+		Price:       25000,
+		Currency:    "IDR",
+		Url:         "/media/" + name + ".jpg",
+		Stock:       10,
+		Status:      entity.ProductStatusDraft,
+		CreatedBy:   "creator",
+	}
+}
+
+func mustCreateProduct(t *testing.T, ctx context.Context, r Repositories, name string) *entity.Product {
+	t.Helper()
+	p := newProduct(name)
+	if err := r.Products.Create(ctx, p); err != nil {
+		t.Fatalf("Create(%q): %v", name, err)
+	}
+	return p
+}
+
+func mustGetProduct(t *testing.T, ctx context.Context, r Repositories, id string) *entity.Product {
+	t.Helper()
+	p, err := r.Products.GetByID(ctx, id)
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	if p == nil {
+		t.Fatalf("GetByID(%s) returned nil", id)
+	}
+	return p
+}
+
+// assertRecent fails unless ts is a recent, non-zero time
+func assertRecent(t *testing.T, field string, ts time.Time) {
+	t.Helper()
+	if d := time.Since(ts); ts.IsZero() || d < -time.Minute || d > time.Minute {
+		t.Errorf("%s = %v, want a recent time", field, ts)
+	}
+}
+
+func testMissingTenant(t *testing.T, _ context.Context, r Repositories) {
+	ctx := context.Background()
+	checks := map[string]error{
+		"Products.Create":           r.Products.Create(ctx, newProduct("no-tenant")),
+		"Products.Delete":           r.Products.Delete(ctx, uuid.NewString()),
+		"Outlets.Create":            r.Outlets.Create(ctx, &entity.Outlet{ID: uuid.NewString(), Name: "no-tenant", CreatedAt: time.Now()}),
+		"Notifications.MarkRead":    r.Notifications.MarkRead(ctx, uuid.NewString(), "user"),
+		"ChangeRequests.Update":     r.ChangeRequests.UpdateStatus(ctx, &entity.ChangeRequest{ID: uuid.NewString()}, entity.ChangeRequestPending),
+		"Products.UpdateImages":     r.Products.UpdateImages(ctx, uuid.NewString(), "", nil),
+		"Products.ReplaceImage":     r.Products.ReplaceImage(ctx, newProduct("no-tenant")),
+		"Audit.Create":              r.Audit.Create(ctx, &entity.AuditEntry{ID: uuid.NewString()}),
+		"Versions.Create":           r.Versions.Create(ctx, &entity.ProductVersion{ProductID: uuid.NewString()}),
+		"Outlets.UpsertSettings":    r.Outlets.UpsertProductSettings(ctx, &entity.ProductOutlet{}),
+		"ChangeRequests.Create":     r.ChangeRequests.Create(ctx, &entity.ChangeRequest{ID: uuid.NewString()}),
+		"Notifications.Create":      r.Notifications.Create(ctx, &entity.Notification{ID: uuid.NewString()}),
+		"Products.UpdateStatus":     r.Products.UpdateStatus(ctx, newProduct("no-tenant"), entity.ProductStatusDraft),
+		"Products.Update":           r.Products.Update(ctx, newProduct("no-tenant")),
+		"Outlets.Delete":            r.Outlets.Delete(ctx, uuid.NewString()),
+		"Outlets.Update":            r.Outlets.Update(ctx, &entity.Outlet{ID: uuid.NewString()}),
+		"Products.AdjustStock":      second(r.Products.AdjustStock(ctx, uuid.NewString(), 1, "user")),
+		"Products.GetByID":          second(r.Products.GetByID(ctx, uuid.NewString())),
+		"Products.GetByIDForUpdate": second(r.Products.GetByIDForUpdate(ctx, uuid.NewString())),
+		"Products.GetAll":           second(r.Products.GetAll(ctx, repository.ProductFilter{})),
+		"Audit.ListByProduct":       second(r.Audit.ListByProduct(ctx, uuid.NewString())),
+		"Versions.ListByProduct":    second(r.Versions.ListByProduct(ctx, uuid.NewString())),
+		"Versions.Get":              second(r.Versions.Get(ctx, uuid.NewString(), 1)),
+		"Outlets.GetAll":            second(r.Outlets.GetAll(ctx)),
+		"Outlets.GetByID":           second(r.Outlets.GetByID(ctx, uuid.NewString())),
+		"ChangeRequests.List":       second(r.ChangeRequests.List(ctx, repository.ChangeRequestFilter{})),
+		"ChangeRequests.GetByID":    second(r.ChangeRequests.GetByID(ctx, uuid.NewString())),
+		"Notifications.ListByUser":  second(r.Notifications.ListByUser(ctx, "user")),
+	}
+	for name, err := range checks {
+		if !errors.Is(err, repository.ErrMissingTenant) {
+			t.Errorf("%s without tenant: got %v, want ErrMissingTenant", name, err)
+		}
+	}
+}
+
+func second[T any](_ T, err error) error {
+	return err
+}
+
+func testProductCreateAndGet(t *testing.T, ctx context.Context, r Repositories) {
+	want := newProduct("latte")
+	want.Availability = []entity.AvailabilityRule{{
+		Weekdays:  []time.Weekday{time.Monday, time.Friday},
+		StartTime: "07:00",
+		EndTime:   "11:00",
+	}}
+	if err := r.Products.Create(ctx, want); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	tenantID, _ := tenant.FromContext(ctx)
+	if want.TenantID != tenantID {
+		t.Errorf("Create set TenantID = %q, want %q", want.TenantID, tenantID)
+	}
+
+	got := mustGetProduct(t, ctx, r, want.ID)
+	if got.TenantID != tenantID || got.Name != want.Name || got.Description != want.Description ||
+		got.Price != want.Price || got.Currency != want.Currency || got.Url != want.Url ||
+		got.Stock != want.Stock || got.Status != want.Status || got.CreatedBy != want.CreatedBy {
+		t.Errorf("GetByID = %+v, want fields of %+v", got, want)
+	}
+	if len(got.Availability) != 1 || got.Availability[0].StartTime != "07:00" || len(got.Availability[0].Weekdays) != 2 {
+		t.Errorf("Availability = %+v, want %+v", got.Availability, want.Availability)
+	}
+	if len(got.Images) != 0 {
+		t.Errorf("Images = %v, want none", got.Images)
+	}
+	assertRecent(t, "CreatedAt", got.CreatedAt)
+	if !got.UpdatedAt.IsZero() {
+		t.Errorf("UpdatedAt = %v, want zero for a product never updated", got.UpdatedAt)
+	}
+	if got.SubmittedAt != nil || got.PublishedAt != nil || got.ArchivedAt != nil {
+		t.Errorf("status timestamps = %v %v %v, want nil", got.SubmittedAt, got.PublishedAt, got.ArchivedAt)
+	}
+
+	// Returned products are copies: changing one must not change the stored product
+	got.Name = "changed"
+	if again := mustGetProduct(t, ctx, r, want.ID); again.Name != want.Name {
+		t.Errorf("stored product changed through a returned copy: Name = %q", again.Name)
+	}
+
+	if p, err := r.Products.GetByID(ctx, uuid.NewString()); p != nil || err != nil {
+		t.Errorf("GetByID(unknown) = %v, %v, want nil, nil", p, err)
+	}
+}
+
+func testProductTenantIsolation(t *testing.T, ctx context.Context, r Repositories) {
+	p := mustCreateProduct(t, ctx, r, "espresso")
+	other := newTenant()
+
+	if got, err := r.Products.GetByID(other, p.ID); got != nil || err != nil {
+		t.Errorf("GetByID from another tenant = %v, %v, want nil, nil", got, err)
+	}
+	if all, err := r.Products.GetAll(other, repository.ProductFilter{}); err != nil || len(all) != 0 {
+		t.Errorf("GetAll from another tenant = %d products, %v, want none", len(all), err)
+	}
+	if err := r.Products.Update(other, &entity.Product{ID: p.ID, Name: "stolen"}); !errors.Is(err, repository.ErrProductNotFound) {
+		t.Errorf("Update from another tenant: got %v, want ErrProductNotFound", err)
+	}
+	if _, err := r.Products.AdjustStock(other, p.ID, -1, "user"); !errors.Is(err, repository.ErrProductNotFound) {
+		t.Errorf("AdjustStock from another tenant: got %v, want ErrProductNotFound", err)
+	}
+	if err := r.Products.Delete(other, p.ID); !errors.Is(err, repository.ErrProductNotFound) {
+		t.Errorf("Delete from another tenant: got %v, want ErrProductNotFound", err)
+	}
+	if got := mustGetProduct(t, ctx, r, p.ID); got.Name != "espresso" || got.Stock != p.Stock {
+		t.Errorf("product changed by another tenant: %+v", got)
+	}
+}
+
+// Names are not unique, products of a tenant may share one
+func testProductSharedName(t *testing.T, ctx context.Context, r Repositories) {
+	mustCreateProduct(t, ctx, r, "mocha")
+	if err := r.Products.Create(ctx, newProduct("mocha")); err != nil {
+		t.Errorf("Create with a name in use: %v", err)
+	}
+
+	p := mustCreateProduct(t, ctx, r, "americano")
+	p.Name = "mocha"
+	if err := r.Products.Update(ctx, p); err != nil {
+		t.Errorf("Update to a name in use: %v", err)
+	}
+}
+
+func testProductDuplicateID(t *testing.T, ctx context.Context, r Repositories) {
+	p := mustCreateProduct(t, ctx, r, "mocha")
+	dup := newProduct("latte")
+	dup.ID = p.ID
+	if err := r.Products.Create(ctx, dup); !errors.Is(err, repository.ErrDuplicateProduct) {
+		t.Errorf("Create with a taken ID: got %v, want ErrDuplicateProduct", err)
+	}
+	if err := r.Products.Create(newTenant(), dup); !errors.Is(err, repository.ErrDuplicateProduct) {
+		t.Errorf("Create with an ID taken in another tenant: got %v, want ErrDuplicateProduct", err)
+	}
+}
+
+func testProductGetAll(t *testing.T, ctx context.Context, r Repositories) {
+	var published []string
+	for i, name := range []string{"a", "b", "c", "d"} {
+		p := newProduct(name)
+		if i%2 == 0 {
+			p.Status = entity.ProductStatusPublished
+			published = append(published, p.ID)
+		}
+		if err := r.Products.Create(ctx, p); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+	}
+
+	all, err := r.Products.GetAll(ctx, repository.ProductFilter{})
+	if err != nil {
+		t.Fatalf("GetAll: %v", err)
+	}
+	if len(all) != 4 {
+		t.Fatalf("GetAll returned %d products, want 4", len(all))
+	}
+	for i := 1; i < len(all); i++ {
+		if all[i-1].ID >= all[i].ID {
+			t.Errorf("GetAll is not ordered by ID: %s before %s", all[i-1].ID, all[i].ID)
+		}
+	}
+
+	filtered, err := r.Products.GetAll(ctx, repository.ProductFilter{Status: entity.ProductStatusPublished})
+	if err != nil {
+		t.Fatalf("GetAll(published): %v", err)
+	}
+	if len(filtered) != len(published) {
+		t.Fatalf("GetAll(published) returned %d products, want %d", len(filtered), len(published))
+	}
+	for _, p := range filtered {
+		if p.Status != entity.ProductStatusPublished {
+			t.Errorf("GetAll(published) returned a %s product", p.Status)
+		}
+	}
+
+	if empty, err := r.Products.GetAll(ctx, repository.ProductFilter{Status: entity.ProductStatusArchived}); err != nil || len(empty) != 0 {
+		t.Errorf("GetAll(archived) = %d products, %v, want none", len(empty), err)
+	}
+}
+
+// A context cancelled while the products are read fails GetAll, it must not return some of them
+func testProductGetAllCancelled(t *testing.T, ctx context.Context, r Repositories) {
+	const count = 200
+	err := r.Tx.WithinTx(ctx, func(ctx context.Context) error {
+		for i := 0; i < count; i++ {
+			if err := r.Products.Create(ctx, newProduct(fmt.Sprintf("product-%d", i))); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	// The cancellation lands at a different point of the query in every attempt
+	for i := 0; i < 50; i++ {
+		ctx, cancel := context.WithCancel(ctx)
+		timer := time.AfterFunc(time.Duration(i)*20*time.Microsecond, cancel)
+		all, err := r.Products.GetAll(ctx, repository.ProductFilter{})
+		timer.Stop()
+		cancel()
+		if err == nil && len(all) != count {
+			t.Fatalf("GetAll with a cancelled context returned %d of %d products and no error", len(all), count)
+		}
+	}
+}
+
+func testProductUpdate(t *testing.T, ctx context.Context, r Repositories) {
+	p := mustCreateProduct(t, ctx, r, "cappuccino")
+
+	p.Name = "cappuccino large"
+	p.Description = "bigger"
+	p.Price = 32000
+	p.Stock = 4
+	p.Url = "/media/stale.jpg"
+	p.Images = map[string]entity.ProductImage{"thumb": {Url: "/media/stale-thumb.jpg", Width: 128, Height: 128}}
+	p.Availability = []entity.AvailabilityRule{{StartDate: "2026-01-01", EndDate: "2026-12-31"}}
+	p.UpdatedBy = "editor"
+	p.Status = entity.ProductStatusPublished
+	if err := r.Products.Update(ctx, p); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	assertRecent(t, "Update set UpdatedAt", p.UpdatedAt)
+
+	got := mustGetProduct(t, ctx, r, p.ID)
+	if got.Name != p.Name || got.Description != p.Description || got.Price != p.Price || got.UpdatedBy != p.UpdatedBy {
+		t.Errorf("GetByID after Update = %+v, want fields of %+v", got, p)
+	}
+	// Stock only changes by AdjustStock, so concurrent adjustments are never lost
+	if got.Stock != 10 {
+		t.Errorf("Update changed stock to %d; only AdjustStock may", got.Stock)
+	}
+	// A stale copy must not overwrite the photo the image worker may have updated meanwhile
+	if got.Url == p.Url || len(got.Images) != 0 {
+		t.Errorf("Update changed the photo to %q %v; only ReplaceImage and UpdateImages may", got.Url, got.Images)
+	}
+	if len(got.Availability) != 1 || got.Availability[0].EndDate != "2026-12-31" {
+		t.Errorf("Availability = %+v", got.Availability)
+	}
+	assertRecent(t, "UpdatedAt", got.UpdatedAt)
+	if got.Status != entity.ProductStatusDraft {
+		t.Errorf("Update changed the status to %s; only UpdateStatus may", got.Status)
+	}
+
+	if err := r.Products.Update(ctx, newProduct("ghost")); !errors.Is(err, repository.ErrProductNotFound) {
+		t.Errorf("Update of an unknown product: got %v, want ErrProductNotFound", err)
+	}
+}
+
+func testProductReplaceImage(t *testing.T, ctx context.Context, r Repositories) {
+	p := mustCreateProduct(t, ctx, r, "cortado")
+	if err := r.Products.UpdateImages(ctx, p.ID, p.Url, map[string]entity.ProductImage{"medium": {Url: "/media/old-m.jpg"}}); err != nil {
+		t.Fatalf("UpdateImages: %v", err)
+	}
+
+	p.Url = "/media/new.jpg"
+	p.Images = nil
+	p.UpdatedBy = "editor"
+	if err := r.Products.ReplaceImage(ctx, p); err != nil {
+		t.Fatalf("ReplaceImage: %v", err)
+	}
+	assertRecent(t, "ReplaceImage set UpdatedAt", p.UpdatedAt)
+
+	got := mustGetProduct(t, ctx, r, p.ID)
+	if got.Url != "/media/new.jpg" || len(got.Images) != 0 || got.UpdatedBy != "editor" {
+		t.Errorf("GetByID after ReplaceImage = url %q images %v by %q, want the new photo without derivatives", got.Url, got.Images, got.UpdatedBy)
+	}
+
+	if err := r.Products.ReplaceImage(ctx, newProduct("ghost")); !errors.Is(err, repository.ErrProductNotFound) {
+		t.Errorf("ReplaceImage of an unknown product: got %v, want ErrProductNotFound", err)
+	}
+}
+
+func testProductUpdateImages(t *testing.T, ctx context.Context, r Repositories) {
+	p := mustCreateProduct(t, ctx, r, "flat white")
+	images := map[string]entity.ProductImage{"medium": {Url: "/media/m.jpg", WebpUrl: "/media/m.webp", Width: 512, Height: 512}}
+
+	if err := r.Products.UpdateImages(ctx, p.ID, "/media/stale.jpg", images); err != nil {
+		t.Fatalf("UpdateImages(stale): %v", err)
+	}
+	if got := mustGetProduct(t, ctx, r, p.ID); len(got.Images) != 0 {
+		t.Errorf("images from a stale upload were stored: %v", got.Images)
+	}
+
+	if err := r.Products.UpdateImages(ctx, p.ID, p.Url, images); err != nil {
+		t.Fatalf("UpdateImages: %v", err)
+	}
+	if got := mustGetProduct(t, ctx, r, p.ID); got.Images["medium"] != images["medium"] {
+		t.Errorf("Images = %v, want %v", got.Images, images)
+	}
+
+	if err := r.Products.UpdateImages(ctx, uuid.NewString(), p.Url, images); err != nil {
+		t.Errorf("UpdateImages of an unknown product: %v, want it ignored", err)
+	}
+}
+
+func testProductAdjustStock(t *testing.T, ctx context.Context, r Repositories) {
+	p := mustCreateProduct(t, ctx, r, "matcha")
+
+	stock, err := r.Products.AdjustStock(ctx, p.ID, -3, "cashier")
+	if err != nil || stock != 7 {
+		t.Fatalf("AdjustStock(-3) = %d, %v, want 7", stock, err)
+	}
+	if stock, err = r.Products.AdjustStock(ctx, p.ID, 5, "cashier"); err != nil || stock != 12 {
+		t.Fatalf("AdjustStock(+5) = %d, %v, want 12", stock, err)
+	}
+	if _, err := r.Products.AdjustStock(ctx, p.ID, -13, "cashier"); !errors.Is(err, repository.ErrInsufficientStock) {
+		t.Errorf("AdjustStock below zero: got %v, want ErrInsufficientStock", err)
+	}
+	if _, err := r.Products.AdjustStock(ctx, uuid.NewString(), 1, "cashier"); !errors.Is(err, repository.ErrProductNotFound) {
+		t.Errorf("AdjustStock of an unknown product: got %v, want ErrProductNotFound", err)
+	}
+
+	got := mustGetProduct(t, ctx, r, p.ID)
+	if got.Stock != 12 || got.UpdatedBy != "cashier" {
+		t.Errorf("after AdjustStock: stock %d, updated by %q", got.Stock, got.UpdatedBy)
+	}
+	assertRecent(t, "UpdatedAt", got.UpdatedAt)
+}
+
+func testProductUpdateStatus(t *testing.T, ctx context.Context, r Repositories) {
+	p := mustCreateProduct(t, ctx, r, "chai")
+
+	now := time.Now().Truncate(time.Second)
+	p.Status = entity.ProductStatusInReview
+	p.SubmittedAt = &now
+	p.UpdatedBy = "barista"
+	if err := r.Products.UpdateStatus(ctx, p, entity.ProductStatusDraft); err != nil {
+		t.Fatalf("UpdateStatus: %v", err)
+	}
+	got := mustGetProduct(t, ctx, r, p.ID)
+	if got.Status != entity.ProductStatusInReview || got.SubmittedAt == nil || !got.SubmittedAt.Equal(now) || got.UpdatedBy != "barista" {
+		t.Errorf("after UpdateStatus: %+v", got)
+	}
+
+	// A second transition from the old status lost the race
+	p.Status = entity.ProductStatusArchived
+	if err := r.Products.UpdateStatus(ctx, p, entity.ProductStatusDraft); !errors.Is(err, repository.ErrStatusConflict) {
+		t.Errorf("UpdateStatus from a stale status: got %v, want ErrStatusConflict", err)
+	}
+	if got := mustGetProduct(t, ctx, r, p.ID); got.Status != entity.ProductStatusInReview {
+		t.Errorf("status = %s after a conflicting UpdateStatus", got.Status)
+	}
+}
+
+func testProductDelete(t *testing.T, ctx context.Context, r Repositories) {
+	p := mustCreateProduct(t, ctx, r, "affogato")
+	if err := r.Products.Delete(ctx, p.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if got, err := r.Products.GetByID(ctx, p.ID); got != nil || err != nil {
+		t.Errorf("GetByID after Delete = %v, %v, want nil, nil", got, err)
+	}
+	if err := r.Products.Delete(ctx, p.ID); !errors.Is(err, repository.ErrProductNotFound) {
+		t.Errorf("second Delete: got %v, want ErrProductNotFound", err)
+	}
+	// The name is free again
+	mustCreateProduct(t, ctx, r, "affogato")
+}
+
+func testProductCounts(t *testing.T, ctx context.Context, r Repositories) {
+	tenantID, _ := tenant.FromContext(ctx)
+	other := newTenant()
+	otherID, _ := tenant.FromContext(other)
+
+	for name, stock := range map[string]int64{"kopi": 2, "teh": 5, "susu": 6} {
+		p := newProduct(name)
+		p.Status = entity.ProductStatusPublished
+		p.Stock = stock
+		if err := r.Products.Create(ctx, p); err != nil {
+			t.Fatalf("Create(%q): %v", name, err)
+		}
+	}
+	// Drafts are not counted
+	draft := newProduct("coklat")
+	draft.Stock = 0
+	if err := r.Products.Create(ctx, draft); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	p := newProduct("jahe")
+	p.Status = entity.ProductStatusPublished
+	if err := r.Products.Create(other, p); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	// Storage may be shared with other tests, so only this test's tenants are checked
+	counts, err := r.Products.CountByTenant(context.Background(), 5)
+	if err != nil {
+		t.Fatalf("CountByTenant: %v", err)
+	}
+	got := map[string]repository.ProductCounts{}
+	for _, c := range counts {
+		got[c.TenantID] = c
+	}
+	if c := got[tenantID]; c.Active != 3 || c.LowStock != 2 {
+		t.Errorf("counts of %s = %+v, want 3 active, 2 low on stock", tenantID, c)
+	}
+	if c := got[otherID]; c.Active != 1 || c.LowStock != 0 {
+		t.Errorf("counts of %s = %+v, want 1 active, 0 low on stock", otherID, c)
+	}
+}
+
+func testAudit(t *testing.T, ctx context.Context, r Repositories) {
+	p := mustCreateProduct(t, ctx, r, "audited")
+	base := time.Now().Truncate(time.Second)
+	for i, action := range []string{entity.AuditActionCreate, entity.AuditActionUpdate} {
+		entry := &entity.AuditEntry{
+			ID:        uuid.NewString(),
+			ProductID: p.ID,
+			Action:    action,
+			ActorID:   "user",
+			ActorName: "User",
+			Changes:   map[string]entity.FieldChange{"name": {From: "old", To: "new"}},
+			CreatedAt: base.Add(time.Duration(i) * time.Second),
+		}
+		if err := r.Audit.Create(ctx, entry); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+	}
+
+	entries, err := r.Audit.ListByProduct(ctx, p.ID)
+	if err != nil {
+		t.Fatalf("ListByProduct: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Action != entity.AuditActionUpdate || entries[1].Action != entity.AuditActionCreate {
+		t.Fatalf("ListByProduct = %+v, want newest first", entries)
+	}
+	if c := entries[0].Changes["name"]; c.From != "old" || c.To != "new" {
+		t.Errorf("Changes = %v", entries[0].Changes)
+	}
+	if !entries[1].CreatedAt.Equal(base) {
+		t.Errorf("CreatedAt = %v, want %v", entries[1].CreatedAt, base)
+	}
+
+	if other, err := r.Audit.ListByProduct(newTenant(), p.ID); err != nil || len(other) != 0 {
+		t.Errorf("ListByProduct from another tenant = %d entries, %v, want none", len(other), err)
+	}
+}
+
+func testVersions(t *testing.T, ctx context.Context, r Repositories) {
+	p := mustCreateProduct(t, ctx, r, "versioned")
+	for i, name := range []string{"v1", "v2", "v3"} {
+		snapshot := *p
+		snapshot.Name = name
+		v := &entity.ProductVersion{ProductID: p.ID, Action: entity.AuditActionUpdate, ActorID: "user", Snapshot: snapshot, CreatedAt: time.Now()}
+		if err := r.Versions.Create(ctx, v); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		if v.Version != i+1 {
+			t.Errorf("Create assigned version %d, want %d", v.Version, i+1)
+		}
+	}
+
+	versions, err := r.Versions.ListByProduct(ctx, p.ID)
+	if err != nil {
+		t.Fatalf("ListByProduct: %v", err)
+	}
+	if len(versions) != 3 || versions[0].Version != 3 || versions[2].Version != 1 {
+		t.Fatalf("ListByProduct returned %d versions, want 3 newest first", len(versions))
+	}
+
+	v, err := r.Versions.Get(ctx, p.ID, 2)
+	if err != nil || v == nil {
+		t.Fatalf("Get(2) = %v, %v", v, err)
+	}
+	if v.Snapshot.Name != "v2" || v.Snapshot.ID != p.ID || v.Action != entity.AuditActionUpdate {
+		t.Errorf("Get(2) = %+v", v)
+	}
+	if v, err := r.Versions.Get(ctx, p.ID, 4); v != nil || err != nil {
+		t.Errorf("Get(unknown) = %v, %v, want nil, nil", v, err)
+	}
+	if v, err := r.Versions.Get(newTenant(), p.ID, 1); v != nil || err != nil {
+		t.Errorf("Get from another tenant = %v, %v, want nil, nil", v, err)
+	}
+}
+
+func testOutlets(t *testing.T, ctx context.Context, r Repositories) {
+	created := time.Now().Truncate(time.Second)
+	for _, name := range []string{"Kemang", "Blok M", "Senopati"} {
+		o := &entity.Outlet{ID: uuid.NewString(), Name: name, Address: name + " street", Timezone: "Asia/Jakarta", Active: true, CreatedAt: created}
+		if err := r.Outlets.Create(ctx, o); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+	}
+
+	outlets, err := r.Outlets.GetAll(ctx)
+	if err != nil {
+		t.Fatalf("GetAll: %v", err)
+	}
+	if len(outlets) != 3 || outlets[0].Name != "Blok M" || outlets[1].Name != "Kemang" || outlets[2].Name != "Senopati" {
+		t.Fatalf("GetAll = %+v, want ordered by name", outlets)
+	}
+
+	o := outlets[0]
+	if !o.CreatedAt.Equal(created) || !o.UpdatedAt.IsZero() || o.Timezone != "Asia/Jakarta" || !o.Active {
+		t.Errorf("outlet = %+v", o)
+	}
+	o.Name = "Blok M Square"
+	o.Active = false
+	if err := r.Outlets.Update(ctx, o); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	got, err := r.Outlets.GetByID(ctx, o.ID)
+	if err != nil || got == nil {
+		t.Fatalf("GetByID = %v, %v", got, err)
+	}
+	if got.Name != "Blok M Square" || got.Active {
+		t.Errorf("after Update: %+v", got)
+	}
+	assertRecent(t, "UpdatedAt", got.UpdatedAt)
+
+	if got, err := r.Outlets.GetByID(newTenant(), o.ID); got != nil || err != nil {
+		t.Errorf("GetByID from another tenant = %v, %v, want nil, nil", got, err)
+	}
+	if err := r.Outlets.Update(ctx, &entity.Outlet{ID: uuid.NewString(), Name: "ghost"}); !errors.Is(err, repository.ErrOutletNotFound) {
+		t.Errorf("Update of an unknown outlet: got %v, want ErrOutletNotFound", err)
+	}
+	if err := r.Outlets.Delete(ctx, o.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := r.Outlets.Delete(ctx, o.ID); !errors.Is(err, repository.ErrOutletNotFound) {
+		t.Errorf("second Delete: got %v, want ErrOutletNotFound", err)
+	}
+}
+
+func testProductSettings(t *testing.T, ctx context.Context, r Repositories) {
+	p := mustCreateProduct(t, ctx, r, "settings")
+	var outletIDs []string
+	for _, name := range []string{"one", "two"} {
+		o := &entity.Outlet{ID: uuid.NewString(), Name: name, Active: true, CreatedAt: time.Now()}
+		if err := r.Outlets.Create(ctx, o); err != nil {
+			t.Fatalf("Create outlet: %v", err)
+		}
+		outletIDs = append(outletIDs, o.ID)
+	}
+
+	price := 30000.0
+	for _, outletID := range outletIDs {
+		s := &entity.ProductOutlet{ProductID: p.ID, OutletID: outletID, PriceOverride: &price, Available: true, UpdatedBy: "manager"}
+		if err := r.Outlets.UpsertProductSettings(ctx, s); err != nil {
+			t.Fatalf("UpsertProductSettings: %v", err)
+		}
+	}
+
+	stock := int64(3)
+	update := &entity.ProductOutlet{ProductID: p.ID, OutletID: outletIDs[0], Stock: &stock, SoldOut: true, UpdatedBy: "barista"}
+	if err := r.Outlets.UpsertProductSettings(ctx, update); err != nil {
+		t.Fatalf("UpsertProductSettings(update): %v", err)
+	}
+	got, err := r.Outlets.GetProductSettings(ctx, p.ID, outletIDs[0])
+	if err != nil || got == nil {
+		t.Fatalf("GetProductSettings = %v, %v", got, err)
+	}
+	if got.PriceOverride != nil || got.Stock == nil || *got.Stock != 3 || got.Available || !got.SoldOut || got.UpdatedBy != "barista" {
+		t.Errorf("settings after upsert = %+v", got)
+	}
+	assertRecent(t, "UpdatedAt", got.UpdatedAt)
+
+	byProduct, err := r.Outlets.ListProductSettingsByProduct(ctx, p.ID)
+	if err != nil || len(byProduct) != 2 {
+		t.Fatalf("ListProductSettingsByProduct = %d settings, %v, want 2", len(byProduct), err)
+	}
+	if byProduct[0].OutletID > byProduct[1].OutletID {
+		t.Error("ListProductSettingsByProduct is not ordered by outlet ID")
+	}
+	byOutlet, err := r.Outlets.ListProductSettingsByOutlet(ctx, outletIDs[1])
+	if err != nil || len(byOutlet) != 1 || byOutlet[p.ID] == nil || *byOutlet[p.ID].PriceOverride != price {
+		t.Errorf("ListProductSettingsByOutlet = %v, %v", byOutlet, err)
+	}
+
+	if s, err := r.Outlets.GetProductSettings(newTenant(), p.ID, outletIDs[0]); s != nil || err != nil {
+		t.Errorf("GetProductSettings from another tenant = %v, %v, want nil, nil", s, err)
+	}
+
+	// Deleting an outlet removes the settings at that outlet
+	if err := r.Outlets.Delete(ctx, outletIDs[0]); err != nil {
+		t.Fatalf("Delete outlet: %v", err)
+	}
+	if s, err := r.Outlets.GetProductSettings(ctx, p.ID, outletIDs[0]); s != nil || err != nil {
+		t.Errorf("GetProductSettings after outlet Delete = %v, %v, want nil, nil", s, err)
+	}
+}
+
+func testChangeRequests(t *testing.T, ctx context.Context, r Repositories) {
+	p := mustCreateProduct(t, ctx, r, "reviewed")
+	other := mustCreateProduct(t, ctx, r, "other")
+	base := time.Now().Truncate(time.Second)
+	name := "renamed"
+
+	var ids []string
+	for i, productID := range []string{p.ID, p.ID, other.ID} {
+		cr := &entity.ChangeRequest{
+			ID:              uuid.NewString(),
+			ProductID:       productID,
+			Status:          entity.ChangeRequestPending,
+			Patch:           entity.ProductPatch{Name: &name},
+			Changes:         map[string]entity.FieldChange{"name": {From: "reviewed", To: name}},
+			RequestedBy:     "barista",
+			RequestedByName: "Barista",
+			CreatedAt:       base.Add(time.Duration(i) * time.Second),
+		}
+		if err := r.ChangeRequests.Create(ctx, cr); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		ids = append(ids, cr.ID)
+	}
+
+	got, err := r.ChangeRequests.GetByID(ctx, ids[0])
+	if err != nil || got == nil {
+		t.Fatalf("GetByID = %v, %v", got, err)
+	}
+	if got.Patch.Name == nil || *got.Patch.Name != name || got.Patch.Price != nil || got.Status != entity.ChangeRequestPending ||
+		got.RequestedByName != "Barista" || !got.CreatedAt.Equal(base) || got.ReviewedAt != nil {
+		t.Errorf("GetByID = %+v", got)
+	}
+	if cr, err := r.ChangeRequests.GetByID(ctx, uuid.NewString()); cr != nil || err != nil {
+		t.Errorf("GetByID(unknown) = %v, %v, want nil, nil", cr, err)
+	}
+
+	all, err := r.ChangeRequests.List(ctx, repository.ChangeRequestFilter{})
+	if err != nil || len(all) != 3 || all[0].ID != ids[2] || all[2].ID != ids[0] {
+		t.Fatalf("List = %d requests, %v, want 3 newest first", len(all), err)
+	}
+	if mine, err := r.ChangeRequests.List(ctx, repository.ChangeRequestFilter{ProductID: p.ID}); err != nil || len(mine) != 2 {
+		t.Errorf("List(product) = %d requests, %v, want 2", len(mine), err)
+	}
+
+	reviewedAt := base.Add(time.Minute)
+	got.Status = entity.ChangeRequestApproved
+	got.ReviewedBy = "manager"
+	got.ReviewComment = "ok"
+	got.ReviewedAt = &reviewedAt
+	if err := r.ChangeRequests.UpdateStatus(ctx, got, entity.ChangeRequestPending); err != nil {
+		t.Fatalf("UpdateStatus: %v", err)
+	}
+	if err := r.ChangeRequests.UpdateStatus(ctx, got, entity.ChangeRequestPending); !errors.Is(err, repository.ErrChangeRequestClosed) {
+		t.Errorf("second UpdateStatus from pending: got %v, want ErrChangeRequestClosed", err)
+	}
+	reviewed, _ := r.ChangeRequests.GetByID(ctx, got.ID)
+	if reviewed == nil || reviewed.Status != entity.ChangeRequestApproved || reviewed.ReviewedBy != "manager" ||
+		reviewed.ReviewComment != "ok" || reviewed.ReviewedAt == nil || !reviewed.ReviewedAt.Equal(reviewedAt) {
+		t.Errorf("after UpdateStatus: %+v", reviewed)
+	}
+
+	if pending, err := r.ChangeRequests.List(ctx, repository.ChangeRequestFilter{Status: entity.ChangeRequestPending}); err != nil || len(pending) != 2 {
+		t.Errorf("List(pending) = %d requests, %v, want 2", len(pending), err)
+	}
+	if others, err := r.ChangeRequests.List(newTenant(), repository.ChangeRequestFilter{}); err != nil || len(others) != 0 {
+		t.Errorf("List from another tenant = %d requests, %v, want none", len(others), err)
+	}
+}
+
+func testNotifications(t *testing.T, ctx context.Context, r Repositories) {
+	base := time.Now().Truncate(time.Second)
+	var ids []string
+	for i, userID := range []string{"alice", "alice", "bob"} {
+		n := &entity.Notification{
+			ID:        uuid.NewString(),
+			UserID:    userID,
+			Type:      entity.NotificationChangeApproved,
+			Message:   "approved",
+			ProductID: uuid.NewString(),
+			CreatedAt: base.Add(time.Duration(i) * time.Second),
+		}
+		if err := r.Notifications.Create(ctx, n); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		ids = append(ids, n.ID)
+	}
+
+	list, err := r.Notifications.ListByUser(ctx, "alice")
+	if err != nil || len(list) != 2 || list[0].ID != ids[1] || list[1].ID != ids[0] {
+		t.Fatalf("ListByUser = %d notifications, %v, want 2 newest first", len(list), err)
+	}
+	if list[1].ReadAt != nil || !list[1].CreatedAt.Equal(base) || list[1].Type != entity.NotificationChangeApproved {
+		t.Errorf("notification = %+v", list[1])
+	}
+
+	if err := r.Notifications.MarkRead(ctx, ids[0], "bob"); !errors.Is(err, repository.ErrNotificationNotFound) {
+		t.Errorf("MarkRead of another user's notification: got %v, want ErrNotificationNotFound", err)
+	}
+	if err := r.Notifications.MarkRead(ctx, uuid.NewString(), "alice"); !errors.Is(err, repository.ErrNotificationNotFound) {
+		t.Errorf("MarkRead of an unknown notification: got %v, want ErrNotificationNotFound", err)
+	}
+	if err := r.Notifications.MarkRead(ctx, ids[0], "alice"); err != nil {
+		t.Fatalf("MarkRead: %v", err)
+	}
+	list, _ = r.Notifications.ListByUser(ctx, "alice")
+	if len(list) != 2 || list[1].ReadAt == nil {
+		t.Fatalf("notification not read after MarkRead: %+v", list)
+	}
+	readAt := *list[1].ReadAt
+	assertRecent(t, "ReadAt", readAt)
+
+	// Marking it read again keeps the first read time
+	if err := r.Notifications.MarkRead(ctx, ids[0], "alice"); err != nil {
+		t.Fatalf("second MarkRead: %v", err)
+	}
+	list, _ = r.Notifications.ListByUser(ctx, "alice")
+	if len(list) != 2 || list[1].ReadAt == nil || !list[1].ReadAt.Equal(readAt) {
+		t.Errorf("second MarkRead changed ReadAt from %v", readAt)
+	}
+
+	if others, err := r.Notifications.ListByUser(newTenant(), "alice"); err != nil || len(others) != 0 {
+		t.Errorf("ListByUser from another tenant = %d notifications, %v, want none", len(others), err)
+	}
+}
+
+func testTransactionCommit(t *testing.T, ctx context.Context, r Repositories) {
+	p := newProduct("committed")
+	err := r.Tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := r.Products.Create(ctx, p); err != nil {
+			return err
+		}
+		// Reads in the transaction see its own writes
+		if got, err := r.Products.GetByID(ctx, p.ID); err != nil || got == nil {
+			return fmt.Errorf("GetByID in transaction = %v, %v", got, err)
+		}
+		if got, err := r.Products.GetByIDForUpdate(ctx, p.ID); err != nil || got == nil || got.Name != p.Name {
+			return fmt.Errorf("GetByIDForUpdate in transaction = %v, %v", got, err)
+		}
+		if got, err := r.Products.GetByIDForUpdate(ctx, uuid.NewString()); err != nil || got != nil {
+			return fmt.Errorf("GetByIDForUpdate(unknown) in transaction = %v, %v, want nil, nil", got, err)
+		}
+		if err := r.Audit.Create(ctx, newAuditEntry(p.ID)); err != nil {
+			return err
+		}
+		return r.Versions.Create(ctx, &entity.ProductVersion{ProductID: p.ID, Action: entity.AuditActionCreate, ActorID: "user", Snapshot: *p, CreatedAt: time.Now()})
+	})
+	if err != nil {
+		t.Fatalf("WithinTx: %v", err)
+	}
+
+	if got, err := r.Products.GetByID(ctx, p.ID); err != nil || got == nil {
+		t.Fatalf("GetByID after commit = %v, %v, want the product", got, err)
+	}
+	if entries, err := r.Audit.ListByProduct(ctx, p.ID); err != nil || len(entries) != 1 {
+		t.Errorf("ListByProduct after commit = %d entries, %v, want 1", len(entries), err)
+	}
+	if versions, err := r.Versions.ListByProduct(ctx, p.ID); err != nil || len(versions) != 1 {
+		t.Errorf("ListByProduct after commit = %d versions, %v, want 1", len(versions), err)
+	}
+}
+
+func testTransactionRollback(t *testing.T, ctx context.Context, r Repositories) {
+	p := mustCreateProduct(t, ctx, r, "rolled-back")
+	created := newProduct("never-created")
+	failure := errors.New("failure")
+
+	err := r.Tx.WithinTx(ctx, func(ctx context.Context) error {
+		changed := *p
+		changed.Name = "renamed"
+		if err := r.Products.Update(ctx, &changed); err != nil {
+			return err
+		}
+		if _, err := r.Products.AdjustStock(ctx, p.ID, -3, "user"); err != nil {
+			return err
+		}
+		if err := r.Products.Create(ctx, created); err != nil {
+			return err
+		}
+		// A nested transaction joins this one and is rolled back with it
+		return r.Tx.WithinTx(ctx, func(ctx context.Context) error {
+			if err := r.Audit.Create(ctx, newAuditEntry(p.ID)); err != nil {
+				return err
+			}
+			if err := r.Versions.Create(ctx, &entity.ProductVersion{ProductID: p.ID, Action: entity.AuditActionUpdate, ActorID: "user", Snapshot: changed, CreatedAt: time.Now()}); err != nil {
+				return err
+			}
+			return failure
+		})
+	})
+	if !errors.Is(err, failure) {
+		t.Fatalf("WithinTx error = %v, want the error of fn", err)
+	}
+
+	got, err := r.Products.GetByID(ctx, p.ID)
+	if err != nil || got == nil {
+		t.Fatalf("GetByID after rollback = %v, %v", got, err)
+	}
+	if got.Name != p.Name || got.Stock != p.Stock {
+		t.Errorf("after rollback name %q stock %d, want %q %d", got.Name, got.Stock, p.Name, p.Stock)
+	}
+	if got, err := r.Products.GetByID(ctx, created.ID); err != nil || got != nil {
+		t.Errorf("GetByID of a product created in the rolled back transaction = %v, %v, want none", got, err)
+	}
+	if entries, err := r.Audit.ListByProduct(ctx, p.ID); err != nil || len(entries) != 0 {
+		t.Errorf("ListByProduct after rollback = %d entries, %v, want none", len(entries), err)
+	}
+	if versions, err := r.Versions.ListByProduct(ctx, p.ID); err != nil || len(versions) != 0 {
+		t.Errorf("ListByProduct after rollback = %d versions, %v, want none", len(versions), err)
+	}
+}
+
+func newAuditEntry(productID string) *entity.AuditEntry {
+	return &entity.AuditEntry{
+		ID:        uuid.NewString(),
+		ProductID: productID,
+		Action:    entity.AuditActionUpdate,
+		ActorID:   "user",
+		CreatedAt: time.Now(),
+	}
+}
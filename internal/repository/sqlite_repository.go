@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// The SQLite schema in migrations/sqlite accepts the same queries as the PostgreSQL one, so
+// the SQLite repositories share their implementation. Only unique violations are reported
+// differently, which isUniqueViolation handles for both.
+
+// OpenSQLite opens the SQLite database file at path, creating it if it does not exist.
+// Foreign keys are enforced and timestamps are stored in a sortable text format.
+func OpenSQLite(path string) (*sql.DB, error) {
+	dsn := "file:" + path + "?_pragma=foreign_keys(1)&_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)&_time_format=sqlite"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sqlite database: %w", err)
+	}
+	// SQLite allows one writer at a time; a single connection queues writes instead of
+	// failing them with SQLITE_BUSY
+	db.SetMaxOpenConns(1)
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to open sqlite database: %w", err)
+	}
+	return db, nil
+}
+
+// NewSQLiteProductRepository creates a ProductRepository backed by SQLite. SQLite has no row
+// locks, GetByIDForUpdate needs none as transactions already run one at a time.
+func NewSQLiteProductRepository(db *sql.DB) ProductRepository {
+	return &sqlProductRepository{db: db}
+}
+
+// NewSQLiteAuditRepository creates an AuditRepository backed by SQLite
+func NewSQLiteAuditRepository(db *sql.DB) AuditRepository {
+	return &sqlAuditRepository{db: db}
+}
+
+// NewSQLiteProductVersionRepository creates a ProductVersionRepository backed by SQLite
+func NewSQLiteProductVersionRepository(db *sql.DB) ProductVersionRepository {
+	return &sqlProductVersionRepository{db: db}
+}
+
+// NewSQLiteOutletRepository creates an OutletRepository backed by SQLite
+func NewSQLiteOutletRepository(db *sql.DB) OutletRepository {
+	return &sqlOutletRepository{db: db}
+}
+
+// NewSQLiteChangeRequestRepository creates a ChangeRequestRepository backed by SQLite
+func NewSQLiteChangeRequestRepository(db *sql.DB) ChangeRequestRepository {
+	return &sqlChangeRequestRepository{db: db}
+}
+
+// NewSQLiteNotificationRepository creates a NotificationRepository backed by SQLite
+func NewSQLiteNotificationRepository(db *sql.DB) NotificationRepository {
+	return &sqlNotificationRepository{db: db}
+}
@@ -0,0 +1,41 @@
+package repository_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/dominikuswilly/nofu-be_product/internal/migrate"
+	"github.com/dominikuswilly/nofu-be_product/internal/repository"
+	"github.com/dominikuswilly/nofu-be_product/internal/repository/repotest"
+	"github.com/dominikuswilly/nofu-be_product/migrations"
+	"go.uber.org/zap"
+)
+
+func TestSQLiteRepositories(t *testing.T) {
+	db, err := repository.OpenSQLite(filepath.Join(t.TempDir(), "product.db"))
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := migrate.New(db, migrate.SQLite, migrations.SQLiteFS, zap.NewNop())
+	if err != nil {
+		t.Fatalf("load migrations: %v", err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	repotest.Run(t, func(t *testing.T) repotest.Repositories {
+		return repotest.Repositories{
+			Products:       repository.NewSQLiteProductRepository(db),
+			Audit:          repository.NewSQLiteAuditRepository(db),
+			Versions:       repository.NewSQLiteProductVersionRepository(db),
+			Outlets:        repository.NewSQLiteOutletRepository(db),
+			ChangeRequests: repository.NewSQLiteChangeRequestRepository(db),
+			Notifications:  repository.NewSQLiteNotificationRepository(db),
+			Tx:             repository.NewSQLTransactor(db),
+		}
+	})
+}
@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// Transactor runs a unit of work atomically across the repositories sharing its storage,
+// e.g. a product change together with its audit entry and revision
+type Transactor interface {
+	// WithinTx calls fn with a context in which every repository call joins one transaction.
+	// The transaction is committed when fn returns nil and rolled back otherwise. Calls
+	// nested in another WithinTx join the outer transaction.
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// txKey is the context key of the *sql.Tx of a transaction
+type txKey struct{}
+
+// dbtx is the part of *sql.DB and *sql.Tx the SQL repositories query through
+type dbtx interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// conn returns the transaction in ctx, or db when the call is not part of one
+func conn(ctx context.Context, db *sql.DB) dbtx {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return tx
+	}
+	return db
+}
+
+// inTx reports whether ctx carries a transaction
+func inTx(ctx context.Context) bool {
+	_, ok := ctx.Value(txKey{}).(*sql.Tx)
+	return ok
+}
+
+type sqlTransactor struct {
+	db *sql.DB
+}
+
+// NewSQLTransactor creates a Transactor for the PostgreSQL or SQLite repositories over db
+func NewSQLTransactor(db *sql.DB) Transactor {
+	return &sqlTransactor{db: db}
+}
+
+func (t *sqlTransactor) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if inTx(ctx) {
+		return fn(ctx)
+	}
+
+	tx, err := t.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	// Rolling back after a commit is a no-op, this only undoes a failed or panicking fn
+	defer tx.Rollback()
+
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
@@ -2,10 +2,14 @@ package server
 
 import (
 	"context"
+	"errors"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/dominikuswilly/nofu-be_product/internal/config"
 	"github.com/dominikuswilly/nofu-be_product/internal/handler"
+	"github.com/dominikuswilly/nofu-be_product/internal/metrics"
 	"github.com/dominikuswilly/nofu-be_product/internal/middleware"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -13,46 +17,108 @@ import (
 
 type Server struct {
 	httpServer *http.Server
-	logger     *zap.Logger
+	// metricsServer serves /metrics on its own port; nil when metrics are not served
+	metricsServer *http.Server
+	logger        *zap.Logger
 }
 
-func NewServer(cfg *config.Config, handler *handler.ProductHandler, logger *zap.Logger) *Server {
-	router := gin.Default()
+func NewServer(cfg *config.Config, handler *handler.ProductHandler, outletHandler *handler.OutletHandler, catalogHandler *handler.CatalogHandler, changeRequestHandler *handler.ChangeRequestHandler, healthHandler *handler.HealthHandler, logger *zap.Logger) *Server {
+	router := gin.New()
+	// gin trusts every proxy by default, letting any client pick its IP with X-Forwarded-For
+	if err := router.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		logger.Fatal("Invalid TRUSTED_PROXIES", zap.Error(err))
+	}
 
-	// Global middleware
-	router.Use(gin.Recovery())
-	router.Use(middleware.CORSMiddleware())
-	// Logger middleware already included in Default, but we can customize if needed
+	// Global middleware. Tracing and the access log come first so they record the final
+	// status and every later middleware can use the request span and logger.
+	router.Use(middleware.Tracing(cfg.Tracing.ServiceName))
+	router.Use(middleware.AccessLog(logger))
+	router.Use(middleware.Metrics())
+	router.Use(middleware.ErrorHandler())
+	router.Use(middleware.Recovery())
+	router.Use(middleware.CORSMiddleware(middleware.CORSOptions{
+		AllowedOrigins:   cfg.CORS.AllowedOrigins,
+		AllowedMethods:   cfg.CORS.AllowedMethods,
+		AllowedHeaders:   cfg.CORS.AllowedHeaders,
+		ExposedHeaders:   cfg.CORS.ExposedHeaders,
+		AllowCredentials: cfg.CORS.AllowCredentials,
+		MaxAge:           cfg.CORS.MaxAge,
+	}))
 
 	// Register routes
 	api := router.Group("/api/product")
 	handler.RegisterRoutes(api)
+	outletHandler.RegisterRoutes(api)
+	catalogHandler.RegisterRoutes(api)
+	changeRequestHandler.RegisterRoutes(api)
+
+	// Serve uploaded media when it is stored locally rather than behind a CDN
+	if strings.HasPrefix(cfg.Media.BaseURL, "/") {
+		router.Static(cfg.Media.BaseURL, cfg.Media.Dir)
+	}
 
-	// Health check
-	router.GET("/health", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	router.NoRoute(func(c *gin.Context) {
+		middleware.Abort(c, middleware.ErrRouteNotFound)
 	})
 
+	// Liveness and readiness probes
+	healthHandler.RegisterRoutes(&router.RouterGroup)
+
 	srv := &http.Server{
-		Addr:    ":" + cfg.AppPort,
-		Handler: router,
+		Addr:              ":" + strconv.Itoa(cfg.Server.Port),
+		Handler:           router,
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
+	}
+
+	// Metrics name tenants and are expensive to collect, so they are kept off the API port
+	var metricsSrv *http.Server
+	if cfg.Metrics.Port != 0 {
+		mux := http.NewServeMux()
+		mux.Handle("GET /metrics", metrics.Handler(logger))
+		metricsSrv = &http.Server{
+			Addr:              ":" + strconv.Itoa(cfg.Metrics.Port),
+			Handler:           mux,
+			ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+			ReadTimeout:       cfg.Server.ReadTimeout,
+			WriteTimeout:      cfg.Server.WriteTimeout,
+			IdleTimeout:       cfg.Server.IdleTimeout,
+		}
 	}
 
 	return &Server{
-		httpServer: srv,
-		logger:     logger,
+		httpServer:    srv,
+		metricsServer: metricsSrv,
+		logger:        logger,
 	}
 }
 
+// Start serves the API and, when enabled, the metrics until either fails or is shut down
 func (s *Server) Start() error {
+	errs := make(chan error, 2)
 	s.logger.Info("Starting server", zap.String("addr", s.httpServer.Addr))
-	if err := s.httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-		return err
+	go func() { errs <- listen(s.httpServer) }()
+	if s.metricsServer != nil {
+		s.logger.Info("Starting metrics server", zap.String("addr", s.metricsServer.Addr))
+		go func() { errs <- listen(s.metricsServer) }()
 	}
-	return nil
+	return <-errs
 }
 
 func (s *Server) Shutdown(ctx context.Context) error {
 	s.logger.Info("Shutting down server...")
-	return s.httpServer.Shutdown(ctx)
+	err := s.httpServer.Shutdown(ctx)
+	if s.metricsServer != nil {
+		err = errors.Join(err, s.metricsServer.Shutdown(ctx))
+	}
+	return err
+}
+
+func listen(srv *http.Server) error {
+	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	return nil
 }
@@ -0,0 +1,17 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// ErrNotFound is returned when the requested object does not exist
+var ErrNotFound = errors.New("object not found")
+
+// Storage defines the interface for storing binary objects such as product images
+type Storage interface {
+	Put(ctx context.Context, key string, r io.Reader, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	URL(key string) string
+}
@@ -0,0 +1,29 @@
+package tenant
+
+import (
+	"context"
+	"regexp"
+)
+
+// Header is the request header used to select a tenant when the principal does not carry one
+const Header = "X-Tenant-ID"
+
+var validID = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// ValidID reports whether id is a well-formed tenant ID
+func ValidID(id string) bool {
+	return validID.MatchString(id)
+}
+
+type tenantKey struct{}
+
+// WithID returns a copy of ctx scoped to tenant id
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, id)
+}
+
+// FromContext returns the tenant ID stored in ctx
+func FromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(tenantKey{}).(string)
+	return id, ok && id != ""
+}
@@ -0,0 +1,113 @@
+// Package tracing sets up OpenTelemetry tracing and W3C trace context propagation
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/dominikuswilly/nofu-be_product/internal/apperror"
+	"github.com/dominikuswilly/nofu-be_product/internal/repository"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ScopeName names the tracer of the service's own spans
+const ScopeName = "github.com/dominikuswilly/nofu-be_product"
+
+// Options selects where spans are exported
+type Options struct {
+	// Exporter is "none", "stdout" or "otlp". OTLP is sent over HTTP and configured with the
+	// standard OTEL_EXPORTER_OTLP_* environment variables.
+	Exporter    string
+	ServiceName string
+	// SampleRatio is the fraction of new traces recorded; traces started by a caller follow
+	// the caller's sampling decision
+	SampleRatio float64
+}
+
+// Setup installs the global tracer provider and the W3C trace context propagator. The
+// returned function flushes pending spans and must be called before exiting. With the
+// "none" exporter no spans are recorded, but incoming trace context is still passed on to
+// the auth service.
+func Setup(ctx context.Context, opts Options) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch opts.Exporter {
+	case "none", "":
+		return func(context.Context) error { return nil }, nil
+	case "stdout":
+		exporter, err = stdouttrace.New()
+	case "otlp":
+		exporter, err = otlptracehttp.New(ctx)
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter %q, expected none, stdout or otlp", opts.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s span exporter: %w", opts.Exporter, err)
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+		resource.WithAttributes(semconv.ServiceName(opts.ServiceName)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe tracing resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(opts.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// Tracer returns the tracer of the service's own spans
+func Tracer() trace.Tracer {
+	return otel.Tracer(ScopeName)
+}
+
+// QueryHook returns a repository hook that wraps every repository call in a client span.
+// system is the storage the repositories run on, e.g. "postgresql".
+func QueryHook(system string) repository.QueryHook {
+	return func(ctx context.Context, repo, method string) (context.Context, func(err error)) {
+		ctx, span := Tracer().Start(ctx, repo+"."+method,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				semconv.DBSystemNameKey.String(system),
+				semconv.DBOperationName(method),
+				attribute.String("repository", repo),
+			),
+		)
+		return ctx, func(err error) {
+			End(span, err)
+		}
+	}
+}
+
+// End ends span, marking it failed when err is an unexpected error. Application errors,
+// such as a missing product, are recorded as events only.
+func End(span trace.Span, err error) {
+	var appErr *apperror.Error
+	switch {
+	case errors.As(err, &appErr):
+		span.AddEvent(appErr.Code)
+	case err != nil:
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
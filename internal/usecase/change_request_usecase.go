@@ -0,0 +1,280 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dominikuswilly/nofu-be_product/internal/apperror"
+	"github.com/dominikuswilly/nofu-be_product/internal/auth"
+	"github.com/dominikuswilly/nofu-be_product/internal/dto"
+	"github.com/dominikuswilly/nofu-be_product/internal/entity"
+	"github.com/dominikuswilly/nofu-be_product/internal/repository"
+	"github.com/google/uuid"
+)
+
+// ErrEmptyChangeRequest is returned when a proposed change would not modify the product
+var ErrEmptyChangeRequest = apperror.Validation("empty_change_request", "Change request contains no changes")
+
+// ChangeRequestUsecase defines the business logic interface for reviewed product changes
+type ChangeRequestUsecase interface {
+	ProposeChange(ctx context.Context, productID string, req dto.UpdateProductRequest) (*dto.ChangeRequestResponse, error)
+	GetChangeRequests(ctx context.Context, query dto.ChangeRequestQuery) ([]*dto.ChangeRequestResponse, error)
+	GetChangeRequestByID(ctx context.Context, id string) (*dto.ChangeRequestResponse, error)
+	ApproveChangeRequest(ctx context.Context, id string, req dto.ReviewChangeRequest) (*dto.ChangeRequestResponse, error)
+	RejectChangeRequest(ctx context.Context, id string, req dto.ReviewChangeRequest) (*dto.ChangeRequestResponse, error)
+
+	GetNotifications(ctx context.Context) ([]*dto.NotificationResponse, error)
+	MarkNotificationRead(ctx context.Context, id string) error
+}
+
+type changeRequestUsecase struct {
+	repo             repository.ChangeRequestRepository
+	productRepo      repository.ProductRepository
+	auditRepo        repository.AuditRepository
+	versionRepo      repository.ProductVersionRepository
+	notificationRepo repository.NotificationRepository
+	// tx writes a review together with the change it applies and the notification
+	tx repository.Transactor
+}
+
+// NewChangeRequestUsecase creates a new changeRequestUsecase
+func NewChangeRequestUsecase(repo repository.ChangeRequestRepository, productRepo repository.ProductRepository, auditRepo repository.AuditRepository, versionRepo repository.ProductVersionRepository, notificationRepo repository.NotificationRepository, tx repository.Transactor) ChangeRequestUsecase {
+	return &changeRequestUsecase{repo: repo, productRepo: productRepo, auditRepo: auditRepo, versionRepo: versionRepo, notificationRepo: notificationRepo, tx: tx}
+}
+
+// ProposeChange stores req as a pending change to the product instead of applying it
+func (u *changeRequestUsecase) ProposeChange(ctx context.Context, productID string, req dto.UpdateProductRequest) (*dto.ChangeRequestResponse, error) {
+	product, err := u.productRepo.GetByID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	if product == nil {
+		return nil, repository.ErrProductNotFound
+	}
+
+	patch := toProductPatch(req)
+	preview := *product
+	applyPatch(&preview, patch)
+	changes := diffProducts(product, &preview)
+	if len(changes) == 0 {
+		return nil, ErrEmptyChangeRequest
+	}
+
+	id, err := uuid.NewV7()
+	if err != nil {
+		return nil, err
+	}
+	cr := &entity.ChangeRequest{
+		ID:          id.String(),
+		ProductID:   productID,
+		Status:      entity.ChangeRequestPending,
+		Patch:       patch,
+		Changes:     changes,
+		RequestedBy: actorID(ctx),
+		CreatedAt:   time.Now(),
+	}
+	if p := auth.PrincipalFromContext(ctx); p != nil {
+		cr.RequestedByName = p.Name
+	}
+	if err := u.repo.Create(ctx, cr); err != nil {
+		return nil, err
+	}
+	return toChangeRequestResponse(cr), nil
+}
+
+func (u *changeRequestUsecase) GetChangeRequests(ctx context.Context, query dto.ChangeRequestQuery) ([]*dto.ChangeRequestResponse, error) {
+	requests, err := u.repo.List(ctx, repository.ChangeRequestFilter{
+		Status:    entity.ChangeRequestStatus(query.Status),
+		ProductID: query.ProductID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]*dto.ChangeRequestResponse, len(requests))
+	for i, cr := range requests {
+		responses[i] = toChangeRequestResponse(cr)
+	}
+	return responses, nil
+}
+
+func (u *changeRequestUsecase) GetChangeRequestByID(ctx context.Context, id string) (*dto.ChangeRequestResponse, error) {
+	cr, err := u.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if cr == nil {
+		return nil, repository.ErrChangeRequestNotFound
+	}
+	return toChangeRequestResponse(cr), nil
+}
+
+// ApproveChangeRequest applies the pending change to the current product and notifies the
+// requester, all in one transaction
+func (u *changeRequestUsecase) ApproveChangeRequest(ctx context.Context, id string, req dto.ReviewChangeRequest) (*dto.ChangeRequestResponse, error) {
+	cr, err := u.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if cr == nil {
+		return nil, repository.ErrChangeRequestNotFound
+	}
+	if cr.Status != entity.ChangeRequestPending {
+		return nil, repository.ErrChangeRequestClosed
+	}
+
+	err = u.tx.WithinTx(ctx, func(ctx context.Context) error {
+		// Claim the request first so that concurrent reviews cannot apply it twice. A failure
+		// further on rolls the claim back, leaving the request pending.
+		review(ctx, cr, entity.ChangeRequestApproved, req.Comment)
+		if err := u.repo.UpdateStatus(ctx, cr, entity.ChangeRequestPending); err != nil {
+			return err
+		}
+
+		// The patch applies to the product as it is now, edits made since the proposal are kept
+		product, err := u.productRepo.GetByIDForUpdate(ctx, cr.ProductID)
+		if err != nil {
+			return err
+		}
+		if product == nil {
+			return repository.ErrProductNotFound
+		}
+		before := *product
+		applyPatch(product, cr.Patch)
+		product.UpdatedBy = actorID(ctx)
+		if err := u.productRepo.Update(ctx, product); err != nil {
+			return err
+		}
+
+		changes := diffProducts(&before, product)
+		changes["changeRequest"] = entity.FieldChange{To: cr.ID}
+		changes["requestedBy"] = entity.FieldChange{To: cr.RequestedBy}
+		if err := recordAudit(ctx, u.auditRepo, product.ID, entity.AuditActionUpdate, changes); err != nil {
+			return err
+		}
+		if err := recordVersion(ctx, u.versionRepo, product, entity.AuditActionUpdate); err != nil {
+			return err
+		}
+		return u.notify(ctx, cr, product, entity.NotificationChangeApproved, "approved")
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toChangeRequestResponse(cr), nil
+}
+
+// RejectChangeRequest closes the pending change without applying it and notifies the requester
+func (u *changeRequestUsecase) RejectChangeRequest(ctx context.Context, id string, req dto.ReviewChangeRequest) (*dto.ChangeRequestResponse, error) {
+	cr, err := u.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if cr == nil {
+		return nil, repository.ErrChangeRequestNotFound
+	}
+	if cr.Status != entity.ChangeRequestPending {
+		return nil, repository.ErrChangeRequestClosed
+	}
+
+	product, err := u.productRepo.GetByID(ctx, cr.ProductID)
+	if err != nil {
+		return nil, err
+	}
+	if product == nil {
+		return nil, repository.ErrProductNotFound
+	}
+
+	err = u.tx.WithinTx(ctx, func(ctx context.Context) error {
+		review(ctx, cr, entity.ChangeRequestRejected, req.Comment)
+		if err := u.repo.UpdateStatus(ctx, cr, entity.ChangeRequestPending); err != nil {
+			return err
+		}
+		return u.notify(ctx, cr, product, entity.NotificationChangeRejected, "rejected")
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toChangeRequestResponse(cr), nil
+}
+
+// GetNotifications lists the notifications of the authenticated user
+func (u *changeRequestUsecase) GetNotifications(ctx context.Context) ([]*dto.NotificationResponse, error) {
+	notifications, err := u.notificationRepo.ListByUser(ctx, actorID(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]*dto.NotificationResponse, len(notifications))
+	for i, n := range notifications {
+		responses[i] = toNotificationResponse(n)
+	}
+	return responses, nil
+}
+
+func (u *changeRequestUsecase) MarkNotificationRead(ctx context.Context, id string) error {
+	return u.notificationRepo.MarkRead(ctx, id, actorID(ctx))
+}
+
+// notify tells the requester of cr about the review outcome
+func (u *changeRequestUsecase) notify(ctx context.Context, cr *entity.ChangeRequest, product *entity.Product, kind, outcome string) error {
+	id, err := uuid.NewV7()
+	if err != nil {
+		return err
+	}
+
+	reviewer := cr.ReviewedBy
+	if p := auth.PrincipalFromContext(ctx); p != nil && p.Name != "" {
+		reviewer = p.Name
+	}
+	message := fmt.Sprintf("Your change to %q was %s by %s", product.Name, outcome, reviewer)
+	if cr.ReviewComment != "" {
+		message += ": " + cr.ReviewComment
+	}
+
+	return u.notificationRepo.Create(ctx, &entity.Notification{
+		ID:              id.String(),
+		UserID:          cr.RequestedBy,
+		Type:            kind,
+		Message:         message,
+		ProductID:       cr.ProductID,
+		ChangeRequestID: cr.ID,
+		CreatedAt:       time.Now(),
+	})
+}
+
+// review records the decision of the principal in ctx on cr
+func review(ctx context.Context, cr *entity.ChangeRequest, status entity.ChangeRequestStatus, comment string) {
+	now := time.Now()
+	cr.Status = status
+	cr.ReviewedBy = actorID(ctx)
+	cr.ReviewComment = comment
+	cr.ReviewedAt = &now
+}
+
+func toChangeRequestResponse(cr *entity.ChangeRequest) *dto.ChangeRequestResponse {
+	return &dto.ChangeRequestResponse{
+		ID:              cr.ID,
+		ProductID:       cr.ProductID,
+		Status:          string(cr.Status),
+		Changes:         toFieldChangeResponses(cr.Changes),
+		RequestedBy:     cr.RequestedBy,
+		RequestedByName: cr.RequestedByName,
+		ReviewedBy:      cr.ReviewedBy,
+		ReviewComment:   cr.ReviewComment,
+		CreatedAt:       cr.CreatedAt,
+		ReviewedAt:      cr.ReviewedAt,
+	}
+}
+
+func toNotificationResponse(n *entity.Notification) *dto.NotificationResponse {
+	return &dto.NotificationResponse{
+		ID:              n.ID,
+		Type:            n.Type,
+		Message:         n.Message,
+		ProductID:       n.ProductID,
+		ChangeRequestID: n.ChangeRequestID,
+		CreatedAt:       n.CreatedAt,
+		ReadAt:          n.ReadAt,
+	}
+}
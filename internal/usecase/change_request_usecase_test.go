@@ -0,0 +1,101 @@
+package usecase
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dominikuswilly/nofu-be_product/internal/auth"
+	"github.com/dominikuswilly/nofu-be_product/internal/dto"
+	"github.com/dominikuswilly/nofu-be_product/internal/entity"
+	"github.com/dominikuswilly/nofu-be_product/internal/repository"
+	"github.com/dominikuswilly/nofu-be_product/internal/tenant"
+)
+
+// readHookProductRepository runs onRead, once it is set, right after the next product read
+type readHookProductRepository struct {
+	repository.ProductRepository
+	once   sync.Once
+	onRead func()
+}
+
+func (r *readHookProductRepository) read() {
+	if r.onRead != nil {
+		r.once.Do(r.onRead)
+	}
+}
+
+func (r *readHookProductRepository) GetByID(ctx context.Context, id string) (*entity.Product, error) {
+	defer r.read()
+	return r.ProductRepository.GetByID(ctx, id)
+}
+
+func (r *readHookProductRepository) GetByIDForUpdate(ctx context.Context, id string) (*entity.Product, error) {
+	defer r.read()
+	return r.ProductRepository.GetByIDForUpdate(ctx, id)
+}
+
+func TestApproveChangeRequestKeepsConcurrentUpdate(t *testing.T) {
+	ctx := tenant.WithID(context.Background(), "tenant-1")
+	as := func(userID, role string) context.Context {
+		return auth.WithPrincipal(ctx, &auth.Principal{UserID: userID, Roles: []string{role}, TenantID: "tenant-1"})
+	}
+
+	products := repository.NewMemoryProductRepository()
+	audit := repository.NewMemoryAuditRepository()
+	versions := repository.NewMemoryProductVersionRepository()
+	tx := repository.NewMemoryTransactor()
+	productUsecase := NewProductUsecase(products, audit, versions, repository.NewMemoryOutletRepository(), tx, nil, nil, time.UTC, auth.DefaultPolicy())
+
+	if err := products.Create(ctx, &entity.Product{
+		ID:       "latte",
+		TenantID: "tenant-1",
+		Name:     "Latte",
+		Price:    4,
+		Currency: "EUR",
+		Url:      "https://example.com/latte.jpg",
+		Status:   entity.ProductStatusPublished,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	hooked := &readHookProductRepository{ProductRepository: products}
+	changeRequests := NewChangeRequestUsecase(repository.NewMemoryChangeRequestRepository(), hooked, audit, versions, repository.NewMemoryNotificationRepository(), tx)
+
+	name := "Oat Latte"
+	cr, err := changeRequests.ProposeChange(as("barista-1", "barista"), "latte", dto.UpdateProductRequest{Name: &name})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A manager changes the price while the change request is being approved
+	updated := make(chan error, 1)
+	hooked.onRead = func() {
+		price := 5.0
+		go func() {
+			_, err := productUsecase.UpdateProduct(as("manager-1", "manager"), "latte", dto.UpdateProductRequest{Price: &price})
+			updated <- err
+		}()
+		// Give the update the chance to land before the approval writes, unless it waits for it
+		select {
+		case err := <-updated:
+			updated <- err
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+
+	if _, err := changeRequests.ApproveChangeRequest(as("owner-1", "owner"), cr.ID, dto.ReviewChangeRequest{}); err != nil {
+		t.Fatalf("ApproveChangeRequest() error = %v", err)
+	}
+	if err := <-updated; err != nil {
+		t.Fatalf("UpdateProduct() error = %v", err)
+	}
+
+	got, err := products.GetByID(ctx, "latte")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != name || got.Price != 5 {
+		t.Errorf("product = %q at %v, want %q at 5", got.Name, got.Price, name)
+	}
+}
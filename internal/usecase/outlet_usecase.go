@@ -0,0 +1,232 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/dominikuswilly/nofu-be_product/internal/dto"
+	"github.com/dominikuswilly/nofu-be_product/internal/entity"
+	"github.com/dominikuswilly/nofu-be_product/internal/repository"
+	"github.com/google/uuid"
+)
+
+// OutletUsecase defines the business logic interface for outlets and per-outlet product settings
+type OutletUsecase interface {
+	CreateOutlet(ctx context.Context, req dto.CreateOutletRequest) (*dto.OutletResponse, error)
+	GetOutletByID(ctx context.Context, id string) (*dto.OutletResponse, error)
+	GetAllOutlets(ctx context.Context) ([]*dto.OutletResponse, error)
+	UpdateOutlet(ctx context.Context, id string, req dto.UpdateOutletRequest) (*dto.OutletResponse, error)
+	DeleteOutlet(ctx context.Context, id string) error
+
+	GetProductOutlets(ctx context.Context, productID string) ([]*dto.ProductOutletResponse, error)
+	UpdateProductOutlet(ctx context.Context, productID, outletID string, req dto.UpdateProductOutletRequest) (*dto.ProductOutletResponse, error)
+}
+
+type outletUsecase struct {
+	repo        repository.OutletRepository
+	productRepo repository.ProductRepository
+	auditRepo   repository.AuditRepository
+	tx          repository.Transactor
+}
+
+// NewOutletUsecase creates a new outletUsecase
+func NewOutletUsecase(repo repository.OutletRepository, productRepo repository.ProductRepository, auditRepo repository.AuditRepository, tx repository.Transactor) OutletUsecase {
+	return &outletUsecase{repo: repo, productRepo: productRepo, auditRepo: auditRepo, tx: tx}
+}
+
+func (u *outletUsecase) CreateOutlet(ctx context.Context, req dto.CreateOutletRequest) (*dto.OutletResponse, error) {
+	newID, err := uuid.NewV7()
+	if err != nil {
+		return nil, err
+	}
+
+	outlet := &entity.Outlet{
+		ID:        newID.String(),
+		Name:      req.Name,
+		Address:   req.Address,
+		Timezone:  req.Timezone,
+		Active:    req.Active == nil || *req.Active,
+		CreatedAt: time.Now(),
+	}
+	if err := u.repo.Create(ctx, outlet); err != nil {
+		return nil, err
+	}
+	return toOutletResponse(outlet), nil
+}
+
+func (u *outletUsecase) GetOutletByID(ctx context.Context, id string) (*dto.OutletResponse, error) {
+	outlet, err := u.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if outlet == nil {
+		return nil, repository.ErrOutletNotFound
+	}
+	return toOutletResponse(outlet), nil
+}
+
+func (u *outletUsecase) GetAllOutlets(ctx context.Context) ([]*dto.OutletResponse, error) {
+	outlets, err := u.repo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]*dto.OutletResponse, len(outlets))
+	for i, o := range outlets {
+		responses[i] = toOutletResponse(o)
+	}
+	return responses, nil
+}
+
+func (u *outletUsecase) UpdateOutlet(ctx context.Context, id string, req dto.UpdateOutletRequest) (*dto.OutletResponse, error) {
+	outlet, err := u.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if outlet == nil {
+		return nil, repository.ErrOutletNotFound
+	}
+
+	if req.Name != nil {
+		outlet.Name = *req.Name
+	}
+	if req.Address != nil {
+		outlet.Address = *req.Address
+	}
+	if req.Timezone != nil {
+		outlet.Timezone = *req.Timezone
+	}
+	if req.Active != nil {
+		outlet.Active = *req.Active
+	}
+
+	if err := u.repo.Update(ctx, outlet); err != nil {
+		return nil, err
+	}
+	return toOutletResponse(outlet), nil
+}
+
+func (u *outletUsecase) DeleteOutlet(ctx context.Context, id string) error {
+	return u.repo.Delete(ctx, id)
+}
+
+func (u *outletUsecase) GetProductOutlets(ctx context.Context, productID string) ([]*dto.ProductOutletResponse, error) {
+	product, err := u.productRepo.GetByID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	if product == nil {
+		return nil, repository.ErrProductNotFound
+	}
+
+	settings, err := u.repo.ListProductSettingsByProduct(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]*dto.ProductOutletResponse, len(settings))
+	for i, s := range settings {
+		responses[i] = toProductOutletResponse(s)
+	}
+	return responses, nil
+}
+
+func (u *outletUsecase) UpdateProductOutlet(ctx context.Context, productID, outletID string, req dto.UpdateProductOutletRequest) (*dto.ProductOutletResponse, error) {
+	product, err := u.productRepo.GetByID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	if product == nil {
+		return nil, repository.ErrProductNotFound
+	}
+
+	outlet, err := u.repo.GetByID(ctx, outletID)
+	if err != nil {
+		return nil, err
+	}
+	if outlet == nil {
+		return nil, repository.ErrOutletNotFound
+	}
+
+	before, err := u.repo.GetProductSettings(ctx, productID, outletID)
+	if err != nil {
+		return nil, err
+	}
+
+	settings := &entity.ProductOutlet{
+		ProductID:     productID,
+		OutletID:      outletID,
+		PriceOverride: req.PriceOverride,
+		Stock:         req.Stock,
+		Available:     req.Available == nil || *req.Available,
+		SoldOut:       req.SoldOut,
+		UpdatedBy:     actorID(ctx),
+	}
+	err = u.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := u.repo.UpsertProductSettings(ctx, settings); err != nil {
+			return err
+		}
+		return recordAudit(ctx, u.auditRepo, productID, entity.AuditActionOutlet, diffProductOutlet(before, settings))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return toProductOutletResponse(settings), nil
+}
+
+// diffProductOutlet lists the settings that differ; before is nil for the first settings of an outlet
+func diffProductOutlet(before, after *entity.ProductOutlet) map[string]entity.FieldChange {
+	if before == nil {
+		before = &entity.ProductOutlet{Available: true}
+	}
+
+	changes := map[string]entity.FieldChange{
+		"outlet": {To: after.OutletID},
+	}
+	if !equalPtr(before.PriceOverride, after.PriceOverride) {
+		changes["priceOverride"] = entity.FieldChange{From: before.PriceOverride, To: after.PriceOverride}
+	}
+	if !equalPtr(before.Stock, after.Stock) {
+		changes["stock"] = entity.FieldChange{From: before.Stock, To: after.Stock}
+	}
+	if before.Available != after.Available {
+		changes["available"] = entity.FieldChange{From: before.Available, To: after.Available}
+	}
+	if before.SoldOut != after.SoldOut {
+		changes["soldOut"] = entity.FieldChange{From: before.SoldOut, To: after.SoldOut}
+	}
+	return changes
+}
+
+func equalPtr[T comparable](a, b *T) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func toOutletResponse(o *entity.Outlet) *dto.OutletResponse {
+	return &dto.OutletResponse{
+		ID:        o.ID,
+		Name:      o.Name,
+		Address:   o.Address,
+		Timezone:  o.Timezone,
+		Active:    o.Active,
+		CreatedAt: o.CreatedAt,
+		UpdatedAt: o.UpdatedAt,
+	}
+}
+
+func toProductOutletResponse(s *entity.ProductOutlet) *dto.ProductOutletResponse {
+	return &dto.ProductOutletResponse{
+		ProductID:     s.ProductID,
+		OutletID:      s.OutletID,
+		PriceOverride: s.PriceOverride,
+		Stock:         s.Stock,
+		Available:     s.Available,
+		SoldOut:       s.SoldOut,
+		UpdatedBy:     s.UpdatedBy,
+		UpdatedAt:     s.UpdatedAt,
+	}
+}
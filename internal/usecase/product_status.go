@@ -0,0 +1,56 @@
+package usecase
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dominikuswilly/nofu-be_product/internal/apperror"
+	"github.com/dominikuswilly/nofu-be_product/internal/entity"
+)
+
+// ErrInvalidTransition is returned when a status transition does not apply to the product's current status
+var ErrInvalidTransition = apperror.Conflict("invalid_status_transition", "Invalid status transition")
+
+// ProductTransition is a named step of the product lifecycle:
+// draft → in_review → published → archived, with review rejection and restoring from the archive.
+type ProductTransition string
+
+const (
+	TransitionSubmit  ProductTransition = "submit"
+	TransitionReject  ProductTransition = "reject"
+	TransitionPublish ProductTransition = "publish"
+	TransitionArchive ProductTransition = "archive"
+	TransitionRestore ProductTransition = "restore"
+)
+
+type statusChange struct {
+	from, to entity.ProductStatus
+}
+
+// productTransitions lists every allowed status change
+var productTransitions = map[ProductTransition]statusChange{
+	TransitionSubmit:  {entity.ProductStatusDraft, entity.ProductStatusInReview},
+	TransitionReject:  {entity.ProductStatusInReview, entity.ProductStatusDraft},
+	TransitionPublish: {entity.ProductStatusInReview, entity.ProductStatusPublished},
+	TransitionArchive: {entity.ProductStatusPublished, entity.ProductStatusArchived},
+	TransitionRestore: {entity.ProductStatusArchived, entity.ProductStatusDraft},
+}
+
+// applyTransition moves p to the target status of t and stamps the time it entered it
+func applyTransition(p *entity.Product, t ProductTransition, at time.Time) error {
+	change, ok := productTransitions[t]
+	if !ok || p.Status != change.from {
+		return ErrInvalidTransition.WithDetail(fmt.Sprintf("cannot %s a product in status %s", t, p.Status))
+	}
+
+	p.Status = change.to
+	switch change.to {
+	case entity.ProductStatusInReview:
+		p.SubmittedAt = &at
+	case entity.ProductStatusPublished:
+		p.PublishedAt = &at
+	case entity.ProductStatusArchived:
+		p.ArchivedAt = &at
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package usecase
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dominikuswilly/nofu-be_product/internal/entity"
+)
+
+func TestApplyTransition(t *testing.T) {
+	earlier := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	at := time.Date(2026, 3, 6, 12, 0, 0, 0, time.UTC)
+	statuses := []entity.ProductStatus{
+		entity.ProductStatusDraft,
+		entity.ProductStatusInReview,
+		entity.ProductStatusPublished,
+		entity.ProductStatusArchived,
+	}
+
+	// stamp returns the timestamp a transition into status sets, if any
+	stamp := func(p *entity.Product, status entity.ProductStatus) **time.Time {
+		switch status {
+		case entity.ProductStatusInReview:
+			return &p.SubmittedAt
+		case entity.ProductStatusPublished:
+			return &p.PublishedAt
+		case entity.ProductStatusArchived:
+			return &p.ArchivedAt
+		}
+		return nil
+	}
+
+	tests := []struct {
+		transition ProductTransition
+		from, to   entity.ProductStatus
+	}{
+		{TransitionSubmit, entity.ProductStatusDraft, entity.ProductStatusInReview},
+		{TransitionReject, entity.ProductStatusInReview, entity.ProductStatusDraft},
+		{TransitionPublish, entity.ProductStatusInReview, entity.ProductStatusPublished},
+		{TransitionArchive, entity.ProductStatusPublished, entity.ProductStatusArchived},
+		{TransitionRestore, entity.ProductStatusArchived, entity.ProductStatusDraft},
+	}
+	for _, tt := range tests {
+		for _, from := range statuses {
+			name := string(tt.transition) + " from " + string(from)
+			t.Run(name, func(t *testing.T) {
+				// Every product has been through the whole lifecycle before
+				p := &entity.Product{Status: from, SubmittedAt: &earlier, PublishedAt: &earlier, ArchivedAt: &earlier}
+				err := applyTransition(p, tt.transition, at)
+
+				if from != tt.from {
+					if !errors.Is(err, ErrInvalidTransition) {
+						t.Fatalf("applyTransition() error = %v, want ErrInvalidTransition", err)
+					}
+					if p.Status != from || !p.SubmittedAt.Equal(earlier) || !p.PublishedAt.Equal(earlier) || !p.ArchivedAt.Equal(earlier) {
+						t.Errorf("a forbidden transition changed the product to %+v", p)
+					}
+					return
+				}
+
+				if err != nil {
+					t.Fatalf("applyTransition() error = %v", err)
+				}
+				if p.Status != tt.to {
+					t.Errorf("status = %s, want %s", p.Status, tt.to)
+				}
+				for _, status := range statuses {
+					ts := stamp(p, status)
+					if ts == nil {
+						continue
+					}
+					want := earlier
+					if status == tt.to {
+						want = at
+					}
+					if !(*ts).Equal(want) {
+						t.Errorf("time entering %s = %v, want %v", status, *ts, want)
+					}
+				}
+			})
+		}
+	}
+
+	t.Run("unknown transition", func(t *testing.T) {
+		p := &entity.Product{Status: entity.ProductStatusDraft}
+		if err := applyTransition(p, "delete", at); !errors.Is(err, ErrInvalidTransition) {
+			t.Errorf("applyTransition() error = %v, want ErrInvalidTransition", err)
+		}
+		if p.Status != entity.ProductStatusDraft {
+			t.Errorf("status = %s, want it unchanged", p.Status)
+		}
+	})
+}
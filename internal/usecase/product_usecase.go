@@ -1,148 +1,714 @@
 package usecase
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
 	"time"
 
+	"github.com/dominikuswilly/nofu-be_product/internal/apperror"
+	"github.com/dominikuswilly/nofu-be_product/internal/auth"
 	"github.com/dominikuswilly/nofu-be_product/internal/dto"
 	"github.com/dominikuswilly/nofu-be_product/internal/entity"
 	"github.com/dominikuswilly/nofu-be_product/internal/repository"
+	"github.com/dominikuswilly/nofu-be_product/internal/storage"
 	"github.com/google/uuid"
 )
 
 // ProductUsecase defines the business logic interface
 type ProductUsecase interface {
 	CreateProduct(ctx context.Context, req dto.CreateProductRequest) (*dto.ProductResponse, error)
-	GetProductByID(ctx context.Context, id string) (*dto.ProductResponse, error)
-	GetAllProducts(ctx context.Context) ([]*dto.ProductResponse, error)
+	GetProductByID(ctx context.Context, id string, query dto.ProductQuery) (*dto.ProductResponse, error)
+	GetAllProducts(ctx context.Context, query dto.ProductQuery) ([]*dto.ProductResponse, error)
 	UpdateProduct(ctx context.Context, id string, req dto.UpdateProductRequest) (*dto.ProductResponse, error)
 	DeleteProduct(ctx context.Context, id string) error
+	UploadProductImage(ctx context.Context, id string, image io.Reader, contentType string) (*dto.ProductResponse, error)
+	GetProductAudit(ctx context.Context, id string) ([]*dto.AuditEntryResponse, error)
+	AdjustStock(ctx context.Context, id string, req dto.AdjustStockRequest) (*dto.ProductResponse, error)
+	GetCatalog(ctx context.Context, query dto.ProductQuery) ([]*dto.CatalogProductResponse, error)
+	GetCatalogProduct(ctx context.Context, id string, query dto.ProductQuery) (*dto.CatalogProductResponse, error)
+	TransitionProduct(ctx context.Context, id string, transition ProductTransition) (*dto.ProductResponse, error)
+	GetProductVersions(ctx context.Context, id string) ([]*dto.ProductVersionResponse, error)
+	DiffProductVersions(ctx context.Context, id string, query dto.ProductVersionDiffQuery) (*dto.ProductVersionDiffResponse, error)
+	RollbackProduct(ctx context.Context, id string, version int) (*dto.ProductResponse, error)
 }
 
+var (
+	// ErrReviewRequired is returned when the caller may not change a published product directly
+	// and has to submit a change request instead
+	ErrReviewRequired = apperror.Forbidden("review_required", "Changes to published products require review")
+	// ErrVersionNotFound is returned when the requested revision of a product does not exist
+	ErrVersionNotFound = apperror.NotFound("product_version_not_found", "Product version not found")
+	// ErrUnsupportedImageType is returned when an uploaded image is not one of imageExtensions
+	ErrUnsupportedImageType = apperror.Validation("unsupported_image_type", "Unsupported image type")
+)
+
+// ImageProcessor schedules background generation of image derivatives
+type ImageProcessor interface {
+	// CheckSize rejects images too large to process, reading only their header
+	CheckSize(image io.Reader) error
+	Enqueue(ctx context.Context, productID, originalKey string) error
+}
+
+// imageExtensions maps the accepted upload content types to file extensions
+var imageExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// systemActor is recorded as the author of changes made without an authenticated principal
+const systemActor = "system"
+
 type productUsecase struct {
-	repo repository.ProductRepository
+	repo        repository.ProductRepository
+	auditRepo   repository.AuditRepository
+	versionRepo repository.ProductVersionRepository
+	outletRepo  repository.OutletRepository
+	// tx writes a change together with its audit entry and revision
+	tx      repository.Transactor
+	storage storage.Storage
+	images  ImageProcessor
+	// location evaluates availability schedules for outlets without their own time zone
+	location *time.Location
+	// policy decides who may change published products without review
+	policy *auth.Policy
 }
 
 // NewProductUsecase creates a new productUsecase
-func NewProductUsecase(repo repository.ProductRepository) ProductUsecase {
-	return &productUsecase{repo: repo}
+func NewProductUsecase(repo repository.ProductRepository, auditRepo repository.AuditRepository, versionRepo repository.ProductVersionRepository, outletRepo repository.OutletRepository, tx repository.Transactor, storage storage.Storage, images ImageProcessor, location *time.Location, policy *auth.Policy) ProductUsecase {
+	return &productUsecase{repo: repo, auditRepo: auditRepo, versionRepo: versionRepo, outletRepo: outletRepo, tx: tx, storage: storage, images: images, location: location, policy: policy}
 }
 
 func (u *productUsecase) CreateProduct(ctx context.Context, req dto.CreateProductRequest) (*dto.ProductResponse, error) {
-	createdBy := "orang"
+	createdBy := actorID(ctx)
 
 	newID, err := uuid.NewV7()
 	if err != nil {
 		return nil, err
 	}
 
-	active := int16(1) // default value is 1
-	if req.Active != nil {
-		if *req.Active {
-			active = 1
-		} else {
-			active = 0
+	product := &entity.Product{
+		ID:           newID.String(),
+		Name:         req.Name,
+		Description:  req.Description,
+		Price:        req.Price,
+		Currency:     req.Currency,
+		Url:          req.Url,
+		Stock:        *req.Stock,
+		Status:       entity.ProductStatusDraft,
+		Availability: toAvailabilityRules(req.Availability),
+		CreatedBy:    createdBy,
+		CreatedAt:    time.Now(),
+	}
+
+	err = u.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := u.repo.Create(ctx, product); err != nil {
+			return err
 		}
+		if err := u.recordAudit(ctx, product.ID, entity.AuditActionCreate, nil); err != nil {
+			return err
+		}
+		return recordVersion(ctx, u.versionRepo, product, entity.AuditActionCreate)
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	product := &entity.Product{
-		ID:          newID.String(),
-		Name:        req.Name,
-		Description: req.Description,
-		Price:       req.Price,
-		Currency:    req.Currency,
-		Url:         req.Url,
-		Stock:       *req.Stock,
-		Active:      active,
-		CreatedBy:   createdBy,
-		CreatedAt:   time.Now(),
+	return toProductResponse(resolveForOutlet(product, "", nil)), nil
+}
+
+func (u *productUsecase) GetProductByID(ctx context.Context, id string, query dto.ProductQuery) (*dto.ProductResponse, error) {
+	if _, err := u.getOutlet(ctx, query.OutletID); err != nil {
+		return nil, err
+	}
+
+	product, err := u.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if product == nil {
+		return nil, repository.ErrProductNotFound
 	}
 
-	if err := u.repo.Create(ctx, product); err != nil {
+	settings, err := u.productSettings(ctx, id, query.OutletID)
+	if err != nil {
 		return nil, err
 	}
+	return toProductResponse(resolveForOutlet(product, query.OutletID, settings)), nil
+}
+
+func (u *productUsecase) GetAllProducts(ctx context.Context, query dto.ProductQuery) ([]*dto.ProductResponse, error) {
+	outlet, err := u.getOutlet(ctx, query.OutletID)
+	if err != nil {
+		return nil, err
+	}
+	settings, err := u.outletSettings(ctx, query.OutletID)
+	if err != nil {
+		return nil, err
+	}
+
+	products, err := u.repo.GetAll(ctx, repository.ProductFilter{Status: entity.ProductStatus(query.Status)})
+	if err != nil {
+		return nil, err
+	}
+
+	at := u.localTime(outlet, query.AvailableAt)
+	responses := make([]*dto.ProductResponse, 0, len(products))
+	for _, p := range products {
+		resolved := resolveForOutlet(p, query.OutletID, settings[p.ID])
+		if at != nil && !isOrderable(resolved, *at) {
+			continue
+		}
+		responses = append(responses, toProductResponse(resolved))
+	}
+	return responses, nil
+}
+
+func (u *productUsecase) UpdateProduct(ctx context.Context, id string, req dto.UpdateProductRequest) (*dto.ProductResponse, error) {
+	var product *entity.Product
+	err := u.tx.WithinTx(ctx, func(ctx context.Context) error {
+		// Read and check the product in the transaction, so that it cannot be published or
+		// edited by someone else before the update is written
+		var err error
+		product, err = u.repo.GetByIDForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+		if product == nil {
+			return repository.ErrProductNotFound
+		}
+		if product.Status == entity.ProductStatusPublished && !u.policy.Allows(auth.PrincipalFromContext(ctx), auth.PermProductPublish) {
+			return ErrReviewRequired
+		}
+		before := *product
+
+		applyPatch(product, toProductPatch(req))
+		product.UpdatedBy = actorID(ctx)
+		if err := u.repo.Update(ctx, product); err != nil {
+			return err
+		}
+		if err := u.recordAudit(ctx, id, entity.AuditActionUpdate, diffProducts(&before, product)); err != nil {
+			return err
+		}
+		return recordVersion(ctx, u.versionRepo, product, entity.AuditActionUpdate)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return toProductResponse(resolveForOutlet(product, "", nil)), nil
+}
 
-	return toProductResponse(product), nil
+func (u *productUsecase) DeleteProduct(ctx context.Context, id string) error {
+	return u.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := u.repo.Delete(ctx, id); err != nil {
+			return err
+		}
+		return u.recordAudit(ctx, id, entity.AuditActionDelete, nil)
+	})
 }
 
-func (u *productUsecase) GetProductByID(ctx context.Context, id string) (*dto.ProductResponse, error) {
+func (u *productUsecase) UploadProductImage(ctx context.Context, id string, image io.Reader, contentType string) (*dto.ProductResponse, error) {
+	ext, ok := imageExtensions[contentType]
+	if !ok {
+		return nil, ErrUnsupportedImageType.WithDetail(fmt.Sprintf("%q is not a supported image type", contentType))
+	}
+
 	product, err := u.repo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 	if product == nil {
-		return nil, nil // Or return a specific ErrNotFound
+		return nil, repository.ErrProductNotFound
+	}
+	// A new photo is shown to customers as soon as it is uploaded, like any other edit
+	if product.Status == entity.ProductStatusPublished && !u.policy.Allows(auth.PrincipalFromContext(ctx), auth.PermProductPublish) {
+		return nil, ErrReviewRequired.WithDetail("changing the photo of a published product requires " + string(auth.PermProductPublish))
 	}
-	return toProductResponse(product), nil
+
+	// Uploads are at most a few megabytes, so they are buffered to check the dimensions
+	// before anything is stored
+	data, err := io.ReadAll(image)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.images.CheckSize(bytes.NewReader(data)); err != nil {
+		var appErr *apperror.Error
+		if errors.As(err, &appErr) {
+			return nil, err
+		}
+		return nil, ErrUnsupportedImageType.WithDetail("the image could not be read")
+	}
+
+	// Every upload gets a fresh key so cached derivatives of the previous photo are never served
+	version, err := uuid.NewV7()
+	if err != nil {
+		return nil, err
+	}
+	key := fmt.Sprintf("products/%s/%s%s", product.ID, version.String(), ext)
+	if err := u.storage.Put(ctx, key, bytes.NewReader(data), contentType); err != nil {
+		return nil, err
+	}
+
+	previousUrl := product.Url
+	product.Url = u.storage.URL(key)
+	product.Images = nil
+	product.UpdatedBy = actorID(ctx)
+	err = u.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := u.repo.ReplaceImage(ctx, product); err != nil {
+			return err
+		}
+		changes := map[string]entity.FieldChange{"url": {From: previousUrl, To: product.Url}}
+		if err := u.recordAudit(ctx, product.ID, entity.AuditActionUploadImage, changes); err != nil {
+			return err
+		}
+		return recordVersion(ctx, u.versionRepo, product, entity.AuditActionUploadImage)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Derivatives are generated once the new photo is committed
+	if err := u.images.Enqueue(ctx, product.ID, key); err != nil {
+		return nil, err
+	}
+
+	return toProductResponse(resolveForOutlet(product, "", nil)), nil
 }
 
-func (u *productUsecase) GetAllProducts(ctx context.Context) ([]*dto.ProductResponse, error) {
-	products, err := u.repo.GetAll(ctx)
+func (u *productUsecase) AdjustStock(ctx context.Context, id string, req dto.AdjustStockRequest) (*dto.ProductResponse, error) {
+	var product *entity.Product
+	err := u.tx.WithinTx(ctx, func(ctx context.Context) error {
+		stock, err := u.repo.AdjustStock(ctx, id, req.Delta, actorID(ctx))
+		if err != nil {
+			return err
+		}
+		changes := map[string]entity.FieldChange{"stock": {From: stock - req.Delta, To: stock}}
+		if req.Reason != "" {
+			changes["reason"] = entity.FieldChange{To: req.Reason}
+		}
+		if err := u.recordAudit(ctx, id, entity.AuditActionAdjustStock, changes); err != nil {
+			return err
+		}
+
+		if product, err = u.repo.GetByID(ctx, id); err != nil {
+			return err
+		}
+		if product == nil {
+			return repository.ErrProductNotFound
+		}
+		return recordVersion(ctx, u.versionRepo, product, entity.AuditActionAdjustStock)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toProductResponse(resolveForOutlet(product, "", nil)), nil
+}
+
+// GetCatalog lists the products visible to anonymous storefront visitors
+func (u *productUsecase) GetCatalog(ctx context.Context, query dto.ProductQuery) ([]*dto.CatalogProductResponse, error) {
+	outlet, err := u.getPublicOutlet(ctx, query.OutletID)
+	if err != nil {
+		return nil, err
+	}
+	settings, err := u.outletSettings(ctx, query.OutletID)
+	if err != nil {
+		return nil, err
+	}
+
+	products, err := u.repo.GetAll(ctx, repository.ProductFilter{Status: entity.ProductStatusPublished})
 	if err != nil {
 		return nil, err
 	}
 
-	responses := make([]*dto.ProductResponse, len(products))
-	for i, p := range products {
-		responses[i] = toProductResponse(p)
+	at := u.localTime(outlet, query.AvailableAt)
+	responses := make([]*dto.CatalogProductResponse, 0, len(products))
+	for _, p := range products {
+		resolved := resolveForOutlet(p, query.OutletID, settings[p.ID])
+		if !resolved.Available {
+			continue
+		}
+		if at != nil && !isOrderable(resolved, *at) {
+			continue
+		}
+		responses = append(responses, toCatalogProductResponse(resolved))
 	}
 	return responses, nil
 }
 
-func (u *productUsecase) UpdateProduct(ctx context.Context, id string, req dto.UpdateProductRequest) (*dto.ProductResponse, error) {
-	// First check if exists
-	existingProduct, err := u.repo.GetByID(ctx, id)
+func (u *productUsecase) GetCatalogProduct(ctx context.Context, id string, query dto.ProductQuery) (*dto.CatalogProductResponse, error) {
+	if _, err := u.getPublicOutlet(ctx, query.OutletID); err != nil {
+		return nil, err
+	}
+
+	product, err := u.repo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
-	if existingProduct == nil {
-		return nil, nil
+	if product == nil {
+		return nil, repository.ErrProductNotFound
 	}
 
-	// Update fields if present
-	if req.Name != nil {
-		existingProduct.Name = *req.Name
+	settings, err := u.productSettings(ctx, id, query.OutletID)
+	if err != nil {
+		return nil, err
 	}
-	if req.Description != nil {
-		existingProduct.Description = *req.Description
+	// Unavailable products are hidden from the storefront as if they did not exist
+	resolved := resolveForOutlet(product, query.OutletID, settings)
+	if !resolved.Available {
+		return nil, repository.ErrProductNotFound
 	}
-	if req.Price != nil {
-		existingProduct.Price = *req.Price
+	return toCatalogProductResponse(resolved), nil
+}
+
+// TransitionProduct moves the product through its lifecycle, e.g. submitting a draft for review
+func (u *productUsecase) TransitionProduct(ctx context.Context, id string, transition ProductTransition) (*dto.ProductResponse, error) {
+	product, err := u.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
 	}
-	if req.Stock != nil {
-		existingProduct.Stock = *req.Stock
+	if product == nil {
+		return nil, repository.ErrProductNotFound
 	}
-	if req.Active != nil {
-		if *req.Active {
-			existingProduct.Active = 1
-		} else {
-			existingProduct.Active = 0
+
+	from := product.Status
+	if err := applyTransition(product, transition, time.Now()); err != nil {
+		return nil, err
+	}
+	product.UpdatedBy = actorID(ctx)
+	err = u.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := u.repo.UpdateStatus(ctx, product, from); err != nil {
+			return err
+		}
+		changes := map[string]entity.FieldChange{"status": {From: from, To: product.Status}}
+		if err := u.recordAudit(ctx, id, entity.AuditActionStatus, changes); err != nil {
+			return err
 		}
+		return recordVersion(ctx, u.versionRepo, product, entity.AuditActionStatus)
+	})
+	if err != nil {
+		return nil, err
 	}
+	return toProductResponse(resolveForOutlet(product, "", nil)), nil
+}
 
-	if err := u.repo.Update(ctx, existingProduct); err != nil {
+// getOutlet returns the outlet with id, or repository.ErrOutletNotFound. An empty id means no outlet.
+func (u *productUsecase) getOutlet(ctx context.Context, id string) (*entity.Outlet, error) {
+	if id == "" {
+		return nil, nil
+	}
+	outlet, err := u.outletRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if outlet == nil {
+		return nil, repository.ErrOutletNotFound
+	}
+	return outlet, nil
+}
+
+// getPublicOutlet is getOutlet for the storefront, which hides inactive outlets
+func (u *productUsecase) getPublicOutlet(ctx context.Context, id string) (*entity.Outlet, error) {
+	outlet, err := u.getOutlet(ctx, id)
+	if err != nil {
 		return nil, err
 	}
+	if outlet != nil && !outlet.Active {
+		return nil, repository.ErrOutletNotFound
+	}
+	return outlet, nil
+}
 
-	return toProductResponse(existingProduct), nil
+// isOrderable reports whether p can be ordered at t, in the local time of its outlet: it must
+// be available, in stock and within its availability schedule
+func isOrderable(p *resolvedProduct, t time.Time) bool {
+	if !p.Available || p.SoldOut {
+		return false
+	}
+	return isScheduled(p.Availability, t)
 }
 
-func (u *productUsecase) DeleteProduct(ctx context.Context, id string) error {
-	return u.repo.Delete(ctx, id)
+// localTime converts t to the local time of outlet (or the default time zone), once per
+// request rather than per product. A nil t stays nil.
+func (u *productUsecase) localTime(outlet *entity.Outlet, t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	local := t.In(u.outletLocation(outlet))
+	return &local
 }
 
-func toProductResponse(p *entity.Product) *dto.ProductResponse {
+func (u *productUsecase) outletLocation(outlet *entity.Outlet) *time.Location {
+	if outlet == nil || outlet.Timezone == "" {
+		return u.location
+	}
+	// Outlet time zones are validated on write; fall back rather than fail listings
+	loc, err := time.LoadLocation(outlet.Timezone)
+	if err != nil {
+		return u.location
+	}
+	return loc
+}
+
+// outletSettings loads the product settings at outletID keyed by product ID, or nil when no outlet is requested
+func (u *productUsecase) outletSettings(ctx context.Context, outletID string) (map[string]*entity.ProductOutlet, error) {
+	if outletID == "" {
+		return nil, nil
+	}
+	return u.outletRepo.ListProductSettingsByOutlet(ctx, outletID)
+}
+
+func (u *productUsecase) productSettings(ctx context.Context, productID, outletID string) (*entity.ProductOutlet, error) {
+	if outletID == "" {
+		return nil, nil
+	}
+	return u.outletRepo.GetProductSettings(ctx, productID, outletID)
+}
+
+func (u *productUsecase) GetProductAudit(ctx context.Context, id string) ([]*dto.AuditEntryResponse, error) {
+	entries, err := u.auditRepo.ListByProduct(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	// Entries outlive their product, so only an ID without any is unknown
+	if len(entries) == 0 {
+		if err := u.requireProduct(ctx, id); err != nil {
+			return nil, err
+		}
+	}
+
+	responses := make([]*dto.AuditEntryResponse, len(entries))
+	for i, e := range entries {
+		responses[i] = toAuditEntryResponse(e)
+	}
+	return responses, nil
+}
+
+// recordAudit appends an audit entry attributed to the principal in ctx
+func (u *productUsecase) recordAudit(ctx context.Context, productID, action string, changes map[string]entity.FieldChange) error {
+	return recordAudit(ctx, u.auditRepo, productID, action, changes)
+}
+
+// recordAudit appends an audit entry attributed to the principal in ctx to repo
+func recordAudit(ctx context.Context, repo repository.AuditRepository, productID, action string, changes map[string]entity.FieldChange) error {
+	id, err := uuid.NewV7()
+	if err != nil {
+		return err
+	}
+
+	entry := &entity.AuditEntry{
+		ID:        id.String(),
+		ProductID: productID,
+		Action:    action,
+		ActorID:   actorID(ctx),
+		Changes:   changes,
+		CreatedAt: time.Now(),
+	}
+	if p := auth.PrincipalFromContext(ctx); p != nil {
+		entry.ActorName = p.Name
+	}
+	return repo.Create(ctx, entry)
+}
+
+// recordVersion stores a snapshot of product as its next revision
+func recordVersion(ctx context.Context, repo repository.ProductVersionRepository, product *entity.Product, action string) error {
+	return repo.Create(ctx, &entity.ProductVersion{
+		ProductID: product.ID,
+		Action:    action,
+		ActorID:   actorID(ctx),
+		Snapshot:  *product,
+		CreatedAt: time.Now(),
+	})
+}
+
+// actorID returns the user ID of the principal in ctx, falling back to systemActor
+func actorID(ctx context.Context) string {
+	if p := auth.PrincipalFromContext(ctx); p != nil {
+		return p.UserID
+	}
+	return systemActor
+}
+
+// diffProducts lists the user-editable fields that differ between before and after
+func diffProducts(before, after *entity.Product) map[string]entity.FieldChange {
+	changes := map[string]entity.FieldChange{}
+	if before.Name != after.Name {
+		changes["name"] = entity.FieldChange{From: before.Name, To: after.Name}
+	}
+	if before.Description != after.Description {
+		changes["description"] = entity.FieldChange{From: before.Description, To: after.Description}
+	}
+	if before.Url != after.Url {
+		changes["url"] = entity.FieldChange{From: before.Url, To: after.Url}
+	}
+	if before.Price != after.Price {
+		changes["price"] = entity.FieldChange{From: before.Price, To: after.Price}
+	}
+	if before.Stock != after.Stock {
+		changes["stock"] = entity.FieldChange{From: before.Stock, To: after.Stock}
+	}
+	if !reflect.DeepEqual(before.Availability, after.Availability) {
+		changes["availability"] = entity.FieldChange{From: before.Availability, To: after.Availability}
+	}
+	if before.Status != after.Status {
+		changes["status"] = entity.FieldChange{From: before.Status, To: after.Status}
+	}
+	return changes
+}
+
+// toProductPatch converts the fields present in req into a patch
+func toProductPatch(req dto.UpdateProductRequest) entity.ProductPatch {
+	patch := entity.ProductPatch{
+		Name:        req.Name,
+		Description: req.Description,
+		Price:       req.Price,
+	}
+	if req.Availability != nil {
+		rules := toAvailabilityRules(*req.Availability)
+		patch.Availability = &rules
+	}
+	return patch
+}
+
+// applyPatch updates the fields of p that are set in patch
+func applyPatch(p *entity.Product, patch entity.ProductPatch) {
+	if patch.Name != nil {
+		p.Name = *patch.Name
+	}
+	if patch.Description != nil {
+		p.Description = *patch.Description
+	}
+	if patch.Price != nil {
+		p.Price = *patch.Price
+	}
+	if patch.Availability != nil {
+		p.Availability = *patch.Availability
+	}
+}
+
+// IsSupportedImageType reports whether contentType can be uploaded as a product image
+func IsSupportedImageType(contentType string) bool {
+	_, ok := imageExtensions[contentType]
+	return ok
+}
+
+// resolvedProduct is a product with its effective values at an outlet
+type resolvedProduct struct {
+	*entity.Product
+	OutletID string
+	// Available is set for published products that are not disabled at the outlet
+	Available bool
+	SoldOut   bool
+}
+
+// resolveForOutlet applies the outlet overrides in settings to p. Products without settings
+// for the outlet keep their global price and stock and are available once published.
+func resolveForOutlet(p *entity.Product, outletID string, settings *entity.ProductOutlet) *resolvedProduct {
+	effective := *p
+	available := p.Status == entity.ProductStatusPublished
+	soldOut := false
+	if settings != nil {
+		if settings.PriceOverride != nil {
+			effective.Price = *settings.PriceOverride
+		}
+		if settings.Stock != nil {
+			effective.Stock = *settings.Stock
+		}
+		if !settings.Available {
+			available = false
+		}
+		soldOut = settings.SoldOut
+	}
+
+	return &resolvedProduct{
+		Product:   &effective,
+		OutletID:  outletID,
+		Available: available,
+		SoldOut:   soldOut || effective.Stock <= 0,
+	}
+}
+
+func toProductResponse(p *resolvedProduct) *dto.ProductResponse {
 	return &dto.ProductResponse{
-		ID:          p.ID,
-		Name:        p.Name,
-		Description: p.Description,
-		Price:       p.Price,
-		CreatedAt:   p.CreatedAt,
-		UpdatedAt:   p.UpdatedAt,
-		Currency:    p.Currency,
-		Url:         p.Url,
-		CreatedBy:   p.CreatedBy,
-		Stock:       p.Stock,
-		Active:      p.Active == 1,
+		ID:           p.ID,
+		Name:         p.Name,
+		Description:  p.Description,
+		Price:        p.Price,
+		CreatedAt:    p.CreatedAt,
+		UpdatedAt:    p.UpdatedAt,
+		Currency:     p.Currency,
+		Url:          p.Url,
+		CreatedBy:    p.CreatedBy,
+		UpdatedBy:    p.UpdatedBy,
+		Stock:        p.Stock,
+		Status:       string(p.Status),
+		SubmittedAt:  p.SubmittedAt,
+		PublishedAt:  p.PublishedAt,
+		ArchivedAt:   p.ArchivedAt,
+		Available:    p.Available,
+		SoldOut:      p.SoldOut,
+		OutletID:     p.OutletID,
+		Images:       toImageResponses(p.Images),
+		Availability: toAvailabilityResponses(p.Availability),
+	}
+}
+
+// toCatalogProductResponse exposes only customer-safe fields: no stock counts or authors
+func toCatalogProductResponse(p *resolvedProduct) *dto.CatalogProductResponse {
+	return &dto.CatalogProductResponse{
+		ID:           p.ID,
+		Name:         p.Name,
+		Description:  p.Description,
+		Price:        p.Price,
+		Currency:     p.Currency,
+		Url:          p.Url,
+		Images:       toImageResponses(p.Images),
+		SoldOut:      p.SoldOut,
+		OutletID:     p.OutletID,
+		Availability: toAvailabilityResponses(p.Availability),
+	}
+}
+
+func toImageResponses(images map[string]entity.ProductImage) map[string]dto.ProductImageResponse {
+	if len(images) == 0 {
+		return nil
+	}
+	responses := make(map[string]dto.ProductImageResponse, len(images))
+	for size, img := range images {
+		responses[size] = dto.ProductImageResponse{
+			Url:     img.Url,
+			WebpUrl: img.WebpUrl,
+			Width:   img.Width,
+			Height:  img.Height,
+		}
+	}
+	return responses
+}
+
+func toAuditEntryResponse(e *entity.AuditEntry) *dto.AuditEntryResponse {
+	return &dto.AuditEntryResponse{
+		ID:        e.ID,
+		ProductID: e.ProductID,
+		Action:    e.Action,
+		ActorID:   e.ActorID,
+		ActorName: e.ActorName,
+		Changes:   toFieldChangeResponses(e.Changes),
+		CreatedAt: e.CreatedAt,
+	}
+}
+
+func toFieldChangeResponses(changes map[string]entity.FieldChange) map[string]dto.FieldChangeResponse {
+	if len(changes) == 0 {
+		return nil
+	}
+	responses := make(map[string]dto.FieldChangeResponse, len(changes))
+	for field, c := range changes {
+		responses[field] = dto.FieldChangeResponse{From: c.From, To: c.To}
 	}
+	return responses
 }
@@ -0,0 +1,141 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/dominikuswilly/nofu-be_product/internal/auth"
+	"github.com/dominikuswilly/nofu-be_product/internal/dto"
+	"github.com/dominikuswilly/nofu-be_product/internal/entity"
+	"github.com/dominikuswilly/nofu-be_product/internal/repository"
+)
+
+func (u *productUsecase) GetProductVersions(ctx context.Context, id string) ([]*dto.ProductVersionResponse, error) {
+	if err := u.requireProduct(ctx, id); err != nil {
+		return nil, err
+	}
+	versions, err := u.versionRepo.ListByProduct(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]*dto.ProductVersionResponse, len(versions))
+	for i, v := range versions {
+		responses[i] = toProductVersionResponse(v)
+	}
+	return responses, nil
+}
+
+// DiffProductVersions lists the fields that changed between two revisions of the product
+func (u *productUsecase) DiffProductVersions(ctx context.Context, id string, query dto.ProductVersionDiffQuery) (*dto.ProductVersionDiffResponse, error) {
+	if err := u.requireProduct(ctx, id); err != nil {
+		return nil, err
+	}
+	from, err := u.getVersion(ctx, id, query.From)
+	if err != nil {
+		return nil, err
+	}
+	to, err := u.getVersion(ctx, id, query.To)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.ProductVersionDiffResponse{
+		From:    from.Version,
+		To:      to.Version,
+		Changes: toFieldChangeResponses(diffProducts(&from.Snapshot, &to.Snapshot)),
+	}, nil
+}
+
+// RollbackProduct restores the content of a previous revision as a new revision. Stock and
+// status are left as they are, since they reflect the current inventory and lifecycle.
+func (u *productUsecase) RollbackProduct(ctx context.Context, id string, version int) (*dto.ProductResponse, error) {
+	var product *entity.Product
+	err := u.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		product, err = u.repo.GetByIDForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+		if product == nil {
+			return repository.ErrProductNotFound
+		}
+		target, err := u.getVersion(ctx, id, version)
+		if err != nil {
+			return err
+		}
+		if product.Status == entity.ProductStatusPublished && !u.policy.Allows(auth.PrincipalFromContext(ctx), auth.PermProductPublish) {
+			return ErrReviewRequired.WithDetail("rolling back a published product requires " + string(auth.PermProductPublish))
+		}
+
+		before := *product
+		restoreSnapshot(product, &target.Snapshot)
+		product.UpdatedBy = actorID(ctx)
+		if err := u.repo.Update(ctx, product); err != nil {
+			return err
+		}
+		// The current derivatives are kept when the photo is unchanged, they may be newer
+		// than the snapshot
+		if product.Url != before.Url {
+			if err := u.repo.ReplaceImage(ctx, product); err != nil {
+				return err
+			}
+		} else {
+			product.Images = before.Images
+		}
+
+		changes := diffProducts(&before, product)
+		changes["version"] = entity.FieldChange{To: version}
+		if err := u.recordAudit(ctx, id, entity.AuditActionRollback, changes); err != nil {
+			return err
+		}
+		return recordVersion(ctx, u.versionRepo, product, entity.AuditActionRollback)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toProductResponse(resolveForOutlet(product, "", nil)), nil
+}
+
+// requireProduct reports ErrProductNotFound unless the product exists. Its revisions are
+// deleted along with it, so this tells an unknown product from one without revisions.
+func (u *productUsecase) requireProduct(ctx context.Context, id string) error {
+	product, err := u.repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if product == nil {
+		return repository.ErrProductNotFound
+	}
+	return nil
+}
+
+func (u *productUsecase) getVersion(ctx context.Context, id string, version int) (*entity.ProductVersion, error) {
+	v, err := u.versionRepo.Get(ctx, id, version)
+	if err != nil {
+		return nil, err
+	}
+	if v == nil {
+		return nil, ErrVersionNotFound
+	}
+	return v, nil
+}
+
+// restoreSnapshot copies the catalog content of snapshot onto p
+func restoreSnapshot(p, snapshot *entity.Product) {
+	p.Name = snapshot.Name
+	p.Description = snapshot.Description
+	p.Price = snapshot.Price
+	p.Url = snapshot.Url
+	p.Images = snapshot.Images
+	p.Availability = snapshot.Availability
+}
+
+func toProductVersionResponse(v *entity.ProductVersion) *dto.ProductVersionResponse {
+	return &dto.ProductVersionResponse{
+		Version:   v.Version,
+		Action:    v.Action,
+		ActorID:   v.ActorID,
+		CreatedAt: v.CreatedAt,
+		Product:   toProductResponse(resolveForOutlet(&v.Snapshot, "", nil)),
+	}
+}
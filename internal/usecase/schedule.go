@@ -0,0 +1,125 @@
+package usecase
+
+import (
+	"time"
+
+	"github.com/dominikuswilly/nofu-be_product/internal/dto"
+	"github.com/dominikuswilly/nofu-be_product/internal/entity"
+)
+
+const (
+	clockLayout = "15:04"
+	dateLayout  = "2006-01-02"
+)
+
+// isScheduled reports whether rules allow ordering at t, which must already be in the
+// outlet's local time. A product without rules is always available.
+func isScheduled(rules []entity.AvailabilityRule, t time.Time) bool {
+	if len(rules) == 0 {
+		return true
+	}
+	for _, rule := range rules {
+		if ruleMatches(rule, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// ruleMatches reports whether t falls in the window of rule. A window ending at or before
+// its start time spans midnight, so one starting and ending at the same time lasts a full
+// day. Rules that cannot be read never match.
+func ruleMatches(rule entity.AvailabilityRule, t time.Time) bool {
+	day := t.Format(dateLayout)
+	minute := t.Hour()*60 + t.Minute()
+	weekday := t.Weekday()
+
+	if rule.StartTime != "" && rule.EndTime != "" {
+		start, okStart := clockMinutes(rule.StartTime)
+		end, okEnd := clockMinutes(rule.EndTime)
+		if !okStart || !okEnd {
+			return false
+		}
+		switch {
+		case start < end:
+			if minute < start || minute >= end {
+				return false
+			}
+		case minute < end:
+			// The early hours of an overnight window belong to the day it opened
+			prev := t.AddDate(0, 0, -1)
+			day, weekday = prev.Format(dateLayout), prev.Weekday()
+		case minute < start:
+			return false
+		}
+	}
+
+	if len(rule.Weekdays) > 0 && !containsWeekday(rule.Weekdays, weekday) {
+		return false
+	}
+	// Dates share the same layout, so they compare correctly as strings
+	if rule.StartDate != "" && day < rule.StartDate {
+		return false
+	}
+	if rule.EndDate != "" && day > rule.EndDate {
+		return false
+	}
+	return true
+}
+
+// clockMinutes converts an "HH:MM" time into minutes after midnight
+func clockMinutes(s string) (int, bool) {
+	t, err := time.Parse(clockLayout, s)
+	if err != nil {
+		return 0, false
+	}
+	return t.Hour()*60 + t.Minute(), true
+}
+
+func containsWeekday(days []time.Weekday, d time.Weekday) bool {
+	for _, day := range days {
+		if day == d {
+			return true
+		}
+	}
+	return false
+}
+
+func toAvailabilityRules(reqs []dto.AvailabilityRuleRequest) []entity.AvailabilityRule {
+	if len(reqs) == 0 {
+		return nil
+	}
+	rules := make([]entity.AvailabilityRule, len(reqs))
+	for i, r := range reqs {
+		weekdays := make([]time.Weekday, len(r.Weekdays))
+		for j, d := range r.Weekdays {
+			weekdays[j] = time.Weekday(d)
+		}
+		rules[i] = entity.AvailabilityRule{
+			Weekdays:  weekdays,
+			StartTime: r.StartTime,
+			EndTime:   r.EndTime,
+			StartDate: r.StartDate,
+			EndDate:   r.EndDate,
+		}
+	}
+	return rules
+}
+
+func toAvailabilityResponses(rules []entity.AvailabilityRule) []dto.AvailabilityRuleResponse {
+	responses := make([]dto.AvailabilityRuleResponse, len(rules))
+	for i, r := range rules {
+		weekdays := make([]int, len(r.Weekdays))
+		for j, d := range r.Weekdays {
+			weekdays[j] = int(d)
+		}
+		responses[i] = dto.AvailabilityRuleResponse{
+			Weekdays:  weekdays,
+			StartTime: r.StartTime,
+			EndTime:   r.EndTime,
+			StartDate: r.StartDate,
+			EndDate:   r.EndDate,
+		}
+	}
+	return responses
+}
@@ -0,0 +1,80 @@
+package usecase
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/dominikuswilly/nofu-be_product/internal/entity"
+)
+
+func TestRuleMatches(t *testing.T) {
+	// 2026-03-06 is a Friday
+	at := func(day int, clock string) time.Time {
+		tm, err := time.Parse("2006-01-02 15:04", fmt.Sprintf("2026-03-%02d %s", day, clock))
+		if err != nil {
+			t.Fatalf("parse %q: %v", clock, err)
+		}
+		return tm
+	}
+	breakfast := entity.AvailabilityRule{StartTime: "07:00", EndTime: "11:00"}
+	lateNight := entity.AvailabilityRule{StartTime: "22:00", EndTime: "02:00"}
+	fridayNight := entity.AvailabilityRule{Weekdays: []time.Weekday{time.Friday}, StartTime: "22:00", EndTime: "02:00"}
+	fullDay := entity.AvailabilityRule{StartTime: "06:00", EndTime: "06:00"}
+	march := entity.AvailabilityRule{StartDate: "2026-03-01", EndDate: "2026-03-31"}
+	marchNights := entity.AvailabilityRule{StartDate: "2026-03-01", EndDate: "2026-03-31", StartTime: "22:00", EndTime: "02:00"}
+
+	tests := []struct {
+		name string
+		rule entity.AvailabilityRule
+		t    time.Time
+		want bool
+	}{
+		{"no restrictions", entity.AvailabilityRule{}, at(6, "03:00"), true},
+		{"at the start of a window", breakfast, at(6, "07:00"), true},
+		{"inside a window", breakfast, at(6, "10:59"), true},
+		{"at the end of a window", breakfast, at(6, "11:00"), false},
+		{"before a window", breakfast, at(6, "06:59"), false},
+		{"overnight before midnight", lateNight, at(6, "23:30"), true},
+		{"overnight after midnight", lateNight, at(7, "01:59"), true},
+		{"overnight at its end", lateNight, at(7, "02:00"), false},
+		{"overnight during the day", lateNight, at(6, "12:00"), false},
+		{"overnight weekday on its opening day", fridayNight, at(6, "23:00"), true},
+		{"overnight weekday after midnight", fridayNight, at(7, "01:00"), true},
+		{"overnight weekday opening the day before", fridayNight, at(6, "01:00"), false},
+		{"equal times span the day", fullDay, at(6, "05:59"), true},
+		{"equal times at the start", fullDay, at(6, "06:00"), true},
+		{"matching weekday", entity.AvailabilityRule{Weekdays: []time.Weekday{time.Friday}}, at(6, "12:00"), true},
+		{"other weekday", entity.AvailabilityRule{Weekdays: []time.Weekday{time.Monday}}, at(6, "12:00"), false},
+		{"first day of a date range", march, at(1, "00:00"), true},
+		{"last day of a date range", march, at(31, "23:59"), true},
+		{"before a date range", march, at(1, "00:00").AddDate(0, 0, -1), false},
+		{"overnight past the end date", marchNights, at(31, "23:00").Add(2 * time.Hour), true},
+		{"overnight opening before the start date", marchNights, at(1, "01:00"), false},
+		{"unreadable start time", entity.AvailabilityRule{StartTime: "7am", EndTime: "11:00"}, at(6, "00:30"), false},
+		{"unreadable end time", entity.AvailabilityRule{StartTime: "00:00", EndTime: "25:00"}, at(6, "00:30"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ruleMatches(tt.rule, tt.t); got != tt.want {
+				t.Errorf("ruleMatches(%+v, %s) = %v, want %v", tt.rule, tt.t.Format("Mon 2006-01-02 15:04"), got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsScheduled(t *testing.T) {
+	now := time.Date(2026, 3, 6, 12, 0, 0, 0, time.UTC)
+	closed := entity.AvailabilityRule{StartTime: "07:00", EndTime: "11:00"}
+	open := entity.AvailabilityRule{StartTime: "11:00", EndTime: "15:00"}
+
+	if !isScheduled(nil, now) {
+		t.Error("a product without rules is not scheduled, want always")
+	}
+	if isScheduled([]entity.AvailabilityRule{closed}, now) {
+		t.Error("scheduled outside its only window")
+	}
+	if !isScheduled([]entity.AvailabilityRule{closed, open}, now) {
+		t.Error("not scheduled although one of its windows matches")
+	}
+}
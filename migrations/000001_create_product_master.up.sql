@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS product_master (
+    c_id          VARCHAR(36)    PRIMARY KEY,
+    c_nm          VARCHAR(255)   NOT NULL,
+    c_description TEXT           NOT NULL DEFAULT '',
+    d_price       NUMERIC(15, 2) NOT NULL,
+    c_currency    VARCHAR(3)     NOT NULL,
+    c_url         TEXT           NOT NULL DEFAULT '',
+    c_created_by  VARCHAR(100)   NOT NULL DEFAULT '',
+    ts_created_at TIMESTAMPTZ    NOT NULL DEFAULT NOW(),
+    ts_updated_at TIMESTAMPTZ,
+    i_stock       BIGINT         NOT NULL DEFAULT 0,
+    i_active      SMALLINT       NOT NULL DEFAULT 1
+);
@@ -0,0 +1 @@
+ALTER TABLE product_master DROP COLUMN IF EXISTS j_images;
@@ -0,0 +1,2 @@
+-- Resized derivatives of c_url, keyed by size name
+ALTER TABLE product_master ADD COLUMN IF NOT EXISTS j_images JSONB;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS product_audit;
+ALTER TABLE product_master DROP COLUMN IF EXISTS c_updated_by;
@@ -0,0 +1,14 @@
+ALTER TABLE product_master ADD COLUMN IF NOT EXISTS c_updated_by VARCHAR(100) NOT NULL DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS product_audit (
+    c_id          VARCHAR(36)  PRIMARY KEY,
+    c_product_id  VARCHAR(36)  NOT NULL,
+    c_action      VARCHAR(32)  NOT NULL,
+    c_actor_id    VARCHAR(100) NOT NULL,
+    c_actor_nm    VARCHAR(255) NOT NULL DEFAULT '',
+    j_changes     JSONB,
+    ts_created_at TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+-- Entries outlive the product they describe, so there is no foreign key
+CREATE INDEX IF NOT EXISTS idx_product_audit_product ON product_audit (c_product_id, ts_created_at DESC);
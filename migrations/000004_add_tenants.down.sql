@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_product_audit_tenant_product;
+CREATE INDEX IF NOT EXISTS idx_product_audit_product ON product_audit (c_product_id, ts_created_at DESC);
+
+DROP INDEX IF EXISTS idx_product_master_tenant;
+
+ALTER TABLE product_audit DROP COLUMN IF EXISTS c_tenant_id;
+ALTER TABLE product_master DROP COLUMN IF EXISTS c_tenant_id;
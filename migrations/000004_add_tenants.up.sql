@@ -0,0 +1,7 @@
+-- Existing rows belong to the brand the service was originally deployed for
+ALTER TABLE product_master ADD COLUMN IF NOT EXISTS c_tenant_id VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE product_audit ADD COLUMN IF NOT EXISTS c_tenant_id VARCHAR(64) NOT NULL DEFAULT 'default';
+
+CREATE INDEX IF NOT EXISTS idx_product_master_tenant ON product_master (c_tenant_id, c_id);
+DROP INDEX IF EXISTS idx_product_audit_product;
+CREATE INDEX IF NOT EXISTS idx_product_audit_tenant_product ON product_audit (c_tenant_id, c_product_id, ts_created_at DESC);
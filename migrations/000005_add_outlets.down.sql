@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS product_outlet;
+DROP TABLE IF EXISTS outlet_master;
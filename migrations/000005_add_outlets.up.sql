@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS outlet_master (
+    c_id          VARCHAR(36)  PRIMARY KEY,
+    c_tenant_id   VARCHAR(64)  NOT NULL,
+    c_nm          VARCHAR(255) NOT NULL,
+    c_address     TEXT         NOT NULL DEFAULT '',
+    b_active      BOOLEAN      NOT NULL DEFAULT TRUE,
+    ts_created_at TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    ts_updated_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_outlet_master_tenant ON outlet_master (c_tenant_id, c_nm);
+
+-- Per-outlet overrides; NULL price or stock falls back to product_master
+CREATE TABLE IF NOT EXISTS product_outlet (
+    c_product_id     VARCHAR(36)    NOT NULL REFERENCES product_master (c_id) ON DELETE CASCADE,
+    c_outlet_id      VARCHAR(36)    NOT NULL REFERENCES outlet_master (c_id) ON DELETE CASCADE,
+    c_tenant_id      VARCHAR(64)    NOT NULL,
+    d_price_override NUMERIC(15, 2),
+    i_stock          BIGINT,
+    b_available      BOOLEAN        NOT NULL DEFAULT TRUE,
+    b_sold_out       BOOLEAN        NOT NULL DEFAULT FALSE,
+    c_updated_by     VARCHAR(100)   NOT NULL DEFAULT '',
+    ts_updated_at    TIMESTAMPTZ    NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (c_product_id, c_outlet_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_outlet_outlet ON product_outlet (c_tenant_id, c_outlet_id);
@@ -0,0 +1,2 @@
+ALTER TABLE outlet_master DROP COLUMN IF EXISTS c_timezone;
+ALTER TABLE product_master DROP COLUMN IF EXISTS j_availability;
@@ -0,0 +1,5 @@
+-- Ordering windows (weekdays, times of day, date ranges); NULL means always available
+ALTER TABLE product_master ADD COLUMN IF NOT EXISTS j_availability JSONB;
+
+-- IANA time zone the schedules are evaluated in; empty uses DEFAULT_TIMEZONE
+ALTER TABLE outlet_master ADD COLUMN IF NOT EXISTS c_timezone VARCHAR(64) NOT NULL DEFAULT '';
@@ -0,0 +1,11 @@
+DROP INDEX IF EXISTS idx_product_master_tenant_status;
+
+ALTER TABLE product_master ADD COLUMN IF NOT EXISTS i_active SMALLINT NOT NULL DEFAULT 1;
+UPDATE product_master SET i_active = CASE WHEN c_status = 'published' THEN 1 ELSE 0 END;
+
+ALTER TABLE product_master
+    DROP CONSTRAINT IF EXISTS ck_product_master_status,
+    DROP COLUMN IF EXISTS c_status,
+    DROP COLUMN IF EXISTS ts_submitted_at,
+    DROP COLUMN IF EXISTS ts_published_at,
+    DROP COLUMN IF EXISTS ts_archived_at;
@@ -0,0 +1,17 @@
+ALTER TABLE product_master
+    ADD COLUMN IF NOT EXISTS c_status        VARCHAR(16) NOT NULL DEFAULT 'draft',
+    ADD COLUMN IF NOT EXISTS ts_submitted_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS ts_published_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS ts_archived_at  TIMESTAMPTZ;
+
+-- Active products were live, inactive ones had been taken off the menu
+UPDATE product_master
+SET c_status        = CASE WHEN i_active = 1 THEN 'published' ELSE 'archived' END,
+    ts_published_at = CASE WHEN i_active = 1 THEN COALESCE(ts_updated_at, ts_created_at) END,
+    ts_archived_at  = CASE WHEN i_active = 1 THEN NULL ELSE COALESCE(ts_updated_at, ts_created_at) END;
+
+ALTER TABLE product_master
+    ADD CONSTRAINT ck_product_master_status CHECK (c_status IN ('draft', 'in_review', 'published', 'archived')),
+    DROP COLUMN i_active;
+
+CREATE INDEX IF NOT EXISTS idx_product_master_tenant_status ON product_master (c_tenant_id, c_status);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS product_notification;
+DROP TABLE IF EXISTS product_change_request;
@@ -0,0 +1,32 @@
+-- Edits to published products awaiting a reviewer's decision
+CREATE TABLE IF NOT EXISTS product_change_request (
+    c_id              VARCHAR(36)  PRIMARY KEY,
+    c_tenant_id       VARCHAR(64)  NOT NULL,
+    c_product_id      VARCHAR(36)  NOT NULL REFERENCES product_master (c_id) ON DELETE CASCADE,
+    c_status          VARCHAR(16)  NOT NULL DEFAULT 'pending' CHECK (c_status IN ('pending', 'approved', 'rejected')),
+    j_patch           JSONB        NOT NULL,
+    j_changes         JSONB,
+    c_requested_by    VARCHAR(100) NOT NULL,
+    c_requested_by_nm VARCHAR(255) NOT NULL DEFAULT '',
+    c_reviewed_by     VARCHAR(100) NOT NULL DEFAULT '',
+    c_review_comment  TEXT         NOT NULL DEFAULT '',
+    ts_created_at     TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    ts_reviewed_at    TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_change_request_tenant_status ON product_change_request (c_tenant_id, c_status, ts_created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_product_change_request_product ON product_change_request (c_product_id);
+
+CREATE TABLE IF NOT EXISTS product_notification (
+    c_id                VARCHAR(36)  PRIMARY KEY,
+    c_tenant_id         VARCHAR(64)  NOT NULL,
+    c_user_id           VARCHAR(100) NOT NULL,
+    c_type              VARCHAR(64)  NOT NULL,
+    c_message           TEXT         NOT NULL,
+    c_product_id        VARCHAR(36)  NOT NULL DEFAULT '',
+    c_change_request_id VARCHAR(36)  NOT NULL DEFAULT '',
+    ts_created_at       TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    ts_read_at          TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_notification_user ON product_notification (c_tenant_id, c_user_id, ts_created_at DESC);
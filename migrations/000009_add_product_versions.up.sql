@@ -0,0 +1,11 @@
+-- Full snapshot of a product after each revision, used for history, diffs and rollback
+CREATE TABLE IF NOT EXISTS product_version (
+    c_tenant_id   VARCHAR(64)  NOT NULL,
+    c_product_id  VARCHAR(36)  NOT NULL REFERENCES product_master (c_id) ON DELETE CASCADE,
+    i_version     INTEGER      NOT NULL,
+    c_action      VARCHAR(32)  NOT NULL,
+    c_actor_id    VARCHAR(100) NOT NULL,
+    j_snapshot    JSONB        NOT NULL,
+    ts_created_at TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (c_product_id, i_version)
+);
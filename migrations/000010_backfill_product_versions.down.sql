@@ -0,0 +1,4 @@
+-- Only removes baselines that no later revision builds on
+DELETE FROM product_version v
+WHERE v.c_action = 'baseline'
+  AND NOT EXISTS (SELECT 1 FROM product_version n WHERE n.c_product_id = v.c_product_id AND n.i_version > v.i_version);
@@ -0,0 +1,33 @@
+-- Products created before revisions were kept get their current state as revision 1, so
+-- that every product has a history to diff against and roll back to. The snapshot has the
+-- JSON layout of entity.Product.
+INSERT INTO product_version (c_tenant_id, c_product_id, i_version, c_action, c_actor_id, j_snapshot, ts_created_at)
+SELECT
+    p.c_tenant_id,
+    p.c_id,
+    1,
+    'baseline',
+    COALESCE(NULLIF(p.c_updated_by, ''), NULLIF(p.c_created_by, ''), 'system'),
+    jsonb_build_object(
+        'id', p.c_id,
+        'tenant_id', p.c_tenant_id,
+        'name', p.c_nm,
+        'description', p.c_description,
+        'price', p.d_price,
+        'currency', p.c_currency,
+        'url', p.c_url,
+        'images', p.j_images,
+        'availability', p.j_availability,
+        'stock', p.i_stock,
+        'status', p.c_status,
+        'submitted_at', p.ts_submitted_at,
+        'published_at', p.ts_published_at,
+        'archived_at', p.ts_archived_at,
+        'created_by', p.c_created_by,
+        'updated_by', p.c_updated_by,
+        'created_at', p.ts_created_at,
+        'updated_at', p.ts_updated_at
+    ),
+    COALESCE(p.ts_updated_at, p.ts_created_at)
+FROM product_master p
+WHERE NOT EXISTS (SELECT 1 FROM product_version v WHERE v.c_product_id = p.c_id);
@@ -0,0 +1,19 @@
+// Package migrations embeds the SQL schema migrations so the service can apply them itself.
+package migrations
+
+import (
+	"embed"
+	"io/fs"
+)
+
+// FS holds the PostgreSQL migrations as NNNNNN_name.up.sql and NNNNNN_name.down.sql files
+//
+//go:embed *.sql
+var FS embed.FS
+
+//go:embed sqlite/*.sql
+var sqliteFiles embed.FS
+
+// SQLiteFS holds the SQLite migrations in the same layout. They are numbered independently
+// of the PostgreSQL ones, so a schema change needs a migration in each.
+var SQLiteFS, _ = fs.Sub(sqliteFiles, "sqlite")
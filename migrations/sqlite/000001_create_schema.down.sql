@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS product_version;
+DROP TABLE IF EXISTS product_notification;
+DROP TABLE IF EXISTS product_change_request;
+DROP TABLE IF EXISTS product_outlet;
+DROP TABLE IF EXISTS outlet_master;
+DROP TABLE IF EXISTS product_audit;
+DROP TABLE IF EXISTS product_master;
@@ -0,0 +1,113 @@
+-- SQLite counterpart of the PostgreSQL schema after 000009_add_product_versions.
+-- JSON is stored as TEXT and timestamps as TIMESTAMP text; foreign keys need PRAGMA foreign_keys = ON.
+CREATE TABLE IF NOT EXISTS product_master (
+    c_id            VARCHAR(36)  PRIMARY KEY,
+    c_tenant_id     VARCHAR(64)  NOT NULL DEFAULT 'default',
+    c_nm            VARCHAR(255) NOT NULL,
+    c_description   TEXT         NOT NULL DEFAULT '',
+    d_price         REAL         NOT NULL,
+    c_currency      VARCHAR(3)   NOT NULL,
+    c_url           TEXT         NOT NULL DEFAULT '',
+    j_images        TEXT,
+    j_availability  TEXT,
+    c_created_by    VARCHAR(100) NOT NULL DEFAULT '',
+    c_updated_by    VARCHAR(100) NOT NULL DEFAULT '',
+    ts_created_at   TIMESTAMP    NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    ts_updated_at   TIMESTAMP,
+    i_stock         INTEGER      NOT NULL DEFAULT 0,
+    c_status        VARCHAR(16)  NOT NULL DEFAULT 'draft' CHECK (c_status IN ('draft', 'in_review', 'published', 'archived')),
+    ts_submitted_at TIMESTAMP,
+    ts_published_at TIMESTAMP,
+    ts_archived_at  TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_master_tenant ON product_master (c_tenant_id, c_id);
+CREATE INDEX IF NOT EXISTS idx_product_master_tenant_status ON product_master (c_tenant_id, c_status);
+
+-- Entries outlive the product they describe, so there is no foreign key
+CREATE TABLE IF NOT EXISTS product_audit (
+    c_id          VARCHAR(36)  PRIMARY KEY,
+    c_tenant_id   VARCHAR(64)  NOT NULL DEFAULT 'default',
+    c_product_id  VARCHAR(36)  NOT NULL,
+    c_action      VARCHAR(32)  NOT NULL,
+    c_actor_id    VARCHAR(100) NOT NULL,
+    c_actor_nm    VARCHAR(255) NOT NULL DEFAULT '',
+    j_changes     TEXT,
+    ts_created_at TIMESTAMP    NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_audit_tenant_product ON product_audit (c_tenant_id, c_product_id, ts_created_at DESC);
+
+CREATE TABLE IF NOT EXISTS outlet_master (
+    c_id          VARCHAR(36)  PRIMARY KEY,
+    c_tenant_id   VARCHAR(64)  NOT NULL,
+    c_nm          VARCHAR(255) NOT NULL,
+    c_address     TEXT         NOT NULL DEFAULT '',
+    c_timezone    VARCHAR(64)  NOT NULL DEFAULT '',
+    b_active      BOOLEAN      NOT NULL DEFAULT TRUE,
+    ts_created_at TIMESTAMP    NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    ts_updated_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_outlet_master_tenant ON outlet_master (c_tenant_id, c_nm);
+
+-- Per-outlet overrides; NULL price or stock falls back to product_master
+CREATE TABLE IF NOT EXISTS product_outlet (
+    c_product_id     VARCHAR(36)  NOT NULL REFERENCES product_master (c_id) ON DELETE CASCADE,
+    c_outlet_id      VARCHAR(36)  NOT NULL REFERENCES outlet_master (c_id) ON DELETE CASCADE,
+    c_tenant_id      VARCHAR(64)  NOT NULL,
+    d_price_override REAL,
+    i_stock          INTEGER,
+    b_available      BOOLEAN      NOT NULL DEFAULT TRUE,
+    b_sold_out       BOOLEAN      NOT NULL DEFAULT FALSE,
+    c_updated_by     VARCHAR(100) NOT NULL DEFAULT '',
+    ts_updated_at    TIMESTAMP    NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    PRIMARY KEY (c_product_id, c_outlet_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_outlet_outlet ON product_outlet (c_tenant_id, c_outlet_id);
+
+-- Edits to published products awaiting a reviewer's decision
+CREATE TABLE IF NOT EXISTS product_change_request (
+    c_id              VARCHAR(36)  PRIMARY KEY,
+    c_tenant_id       VARCHAR(64)  NOT NULL,
+    c_product_id      VARCHAR(36)  NOT NULL REFERENCES product_master (c_id) ON DELETE CASCADE,
+    c_status          VARCHAR(16)  NOT NULL DEFAULT 'pending' CHECK (c_status IN ('pending', 'approved', 'rejected')),
+    j_patch           TEXT         NOT NULL,
+    j_changes         TEXT,
+    c_requested_by    VARCHAR(100) NOT NULL,
+    c_requested_by_nm VARCHAR(255) NOT NULL DEFAULT '',
+    c_reviewed_by     VARCHAR(100) NOT NULL DEFAULT '',
+    c_review_comment  TEXT         NOT NULL DEFAULT '',
+    ts_created_at     TIMESTAMP    NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    ts_reviewed_at    TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_change_request_tenant_status ON product_change_request (c_tenant_id, c_status, ts_created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_product_change_request_product ON product_change_request (c_product_id);
+
+CREATE TABLE IF NOT EXISTS product_notification (
+    c_id                VARCHAR(36)  PRIMARY KEY,
+    c_tenant_id         VARCHAR(64)  NOT NULL,
+    c_user_id           VARCHAR(100) NOT NULL,
+    c_type              VARCHAR(64)  NOT NULL,
+    c_message           TEXT         NOT NULL,
+    c_product_id        VARCHAR(36)  NOT NULL DEFAULT '',
+    c_change_request_id VARCHAR(36)  NOT NULL DEFAULT '',
+    ts_created_at       TIMESTAMP    NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    ts_read_at          TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_notification_user ON product_notification (c_tenant_id, c_user_id, ts_created_at DESC);
+
+-- Full snapshot of a product after each revision, used for history, diffs and rollback
+CREATE TABLE IF NOT EXISTS product_version (
+    c_tenant_id   VARCHAR(64)  NOT NULL,
+    c_product_id  VARCHAR(36)  NOT NULL REFERENCES product_master (c_id) ON DELETE CASCADE,
+    i_version     INTEGER      NOT NULL,
+    c_action      VARCHAR(32)  NOT NULL,
+    c_actor_id    VARCHAR(100) NOT NULL,
+    j_snapshot    TEXT         NOT NULL,
+    ts_created_at TIMESTAMP    NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    PRIMARY KEY (c_product_id, i_version)
+);